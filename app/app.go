@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"godas/controller"
+	"godas/mailer"
 	"godas/middleware"
+	"godas/model/domain"
+	"godas/repository"
 	"godas/secure"
-	"math/rand"
+	"godas/service"
 	"os"
 	"strconv"
 	"time"
@@ -20,13 +23,21 @@ import (
 )
 
 type App struct {
-	client        *mongo.Client
-	Core          *fiber.App
-	Ctx           context.Context
-	DB            *mongo.Database
-	SnowflakeNode *snowflake.Node
-	Validate      *validator.Validate
-	JWTProvider   *secure.JWTProvider
+	client         *mongo.Client
+	Core           *fiber.App
+	Ctx            context.Context
+	DB             *mongo.Database
+	SnowflakeNode  *snowflake.Node
+	Validate       *validator.Validate
+	JWTProvider    *secure.JWTProvider
+	PasswordHasher *secure.PasswordHasher
+	Mailer         mailer.Mailer
+
+	RefreshTokenExpiration time.Duration
+	UserCacheTTL           time.Duration
+	ItemMaxSize            int
+	VerificationCodeLength int
+	VerificationLinkURL    string
 }
 
 func New(test bool) *App {
@@ -57,33 +68,139 @@ func New(test bool) *App {
 		panic(err)
 	}
 	app.Validate = validator.New()
-	app.JWTProvider = secure.NewJWTProvider(secure.DefaultJWTExpiration, os.Getenv("APP_NAME"), os.Getenv("JWT_SIGNATURE_KEY"))
-	rand.Seed(time.Now().UnixNano())
+	accessTokenExpiration := durationFromEnv("ACCESS_TOKEN_LIFETIME", secure.DefaultJWTExpiration)
+	switch signingAlgorithm := os.Getenv("JWT_SIGNING_ALGORITHM"); signingAlgorithm {
+	case "", secure.SigningAlgorithmHS256:
+		app.JWTProvider = secure.NewJWTProvider(accessTokenExpiration, os.Getenv("APP_NAME"), os.Getenv("JWT_SIGNATURE_KEY"))
+	default:
+		app.JWTProvider, err = secure.NewRotatingJWTProvider(
+			accessTokenExpiration, os.Getenv("APP_NAME"), signingAlgorithm, repository.NewSigningKeyRepository(app.DB),
+			durationFromEnv("JWT_KEY_ROTATION", secure.DefaultKeyRotation), durationFromEnv("JWT_KEY_GRACE_PERIOD", secure.DefaultKeyGracePeriod),
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+	app.RefreshTokenExpiration = durationFromEnv("REFRESH_TOKEN_LIFETIME", secure.DefaultRefreshTokenExpiration)
+	app.UserCacheTTL = durationFromEnv("USER_CACHE_TTL", repository.DefaultUserCacheTTL)
+	app.PasswordHasher, err = secure.NewPasswordHasher(os.Getenv("PASSWORD_HASH_ALGORITHM"))
+	if err != nil {
+		panic(err)
+	}
+
+	app.ItemMaxSize = service.DefaultItemMaxSize
+	if itemMaxSizeString := os.Getenv("ITEM_MAX_SIZE"); itemMaxSizeString != "" {
+		app.ItemMaxSize, err = strconv.Atoi(itemMaxSizeString)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	app.VerificationCodeLength = secure.DefaultVerificationCodeLength
+	if codeLengthString := os.Getenv("VERIFICATION_CODE_LENGTH"); codeLengthString != "" {
+		app.VerificationCodeLength, err = strconv.Atoi(codeLengthString)
+		if err != nil {
+			panic(err)
+		}
+		if app.VerificationCodeLength < secure.MinVerificationCodeLength || app.VerificationCodeLength > secure.MaxVerificationCodeLength {
+			panic(fmt.Sprintf("VERIFICATION_CODE_LENGTH must be between %d and %d", secure.MinVerificationCodeLength, secure.MaxVerificationCodeLength))
+		}
+	}
+	app.VerificationLinkURL = os.Getenv("VERIFICATION_LINK_URL")
+
+	switch transport := os.Getenv("EMAIL_TRANSPORT"); transport {
+	case "", mailer.TransportSMTP:
+		app.Mailer, err = mailer.NewSMTPMailer(os.Getenv("EMAIL_HOST"), os.Getenv("EMAIL_PORT"), os.Getenv("EMAIL"), os.Getenv("EMAIL_PASSWORD"), os.Getenv("EMAIL_SECURITY"))
+	case mailer.TransportFile:
+		emailDirectory := os.Getenv("EMAIL_DIRECTORY")
+		if emailDirectory == "" {
+			emailDirectory = "mail"
+		}
+		app.Mailer, err = mailer.NewFileMailer(emailDirectory)
+	case mailer.TransportMemory:
+		app.Mailer = mailer.NewMemoryMailer()
+	default:
+		err = fmt.Errorf("%w: %s", mailer.ErrUnknownTransport, transport)
+	}
+	if err != nil {
+		panic(err)
+	}
 
 	return app
 }
 
+// Read a duration such as 15m or 720h from the environment
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	durationString := os.Getenv(key)
+	if durationString == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(durationString)
+	if err != nil {
+		panic(err)
+	}
+
+	return duration
+}
+
 func (app *App) SetupRouter(
 	userController controller.UserController,
 	authController controller.AuthController,
+	roleController controller.RoleController,
+	apiKeyController controller.APIKeyController,
+	mfaController controller.MFAController,
 	stackController controller.StackController,
+	queueController controller.QueueController,
+	treeController controller.TreeController,
+	orderedMapController controller.OrderedMapController,
 	docsController controller.DocsController,
 	authMiddleware *middleware.AuthMiddleware,
 ) {
 	// Auth Controller
 	app.Core.Post("/signin", authController.Signin)
+	app.Core.Post("/signin/mfa", authController.SigninMFA)
+	app.Core.Post("/token/refresh", authController.Refresh)
+	app.Core.Get("/.well-known/jwks.json", authController.JWKS)
 	app.Core.Post("/signup", authController.Signup)
 	app.Core.Post("/verification", authController.EmailVerification)
 	app.Core.Post("/resend", authController.ResendEmailVerification)
-	app.Core.Use(authMiddleware.Use("/users", "/stacks"))
+	app.Core.Post("/password/forgot", authController.ForgotPassword)
+	app.Core.Post("/password/reset", authController.ResetPassword)
+	app.Core.Use(authMiddleware.Use("/signout", "/users", "/roles", "/stacks", "/queues", "/trees", "/maps"))
+	app.Core.Post("/signout", authController.Signout)
 
 	// User Controller
 	usersGroup := app.Core.Group("/users")
-	usersGroup.Post("", userController.Create)
+	usersGroup.Post("", middleware.RequirePermission(domain.PermissionUsersCreate), userController.Create)
 	usersGroup.Get("/:id", userController.FindById)
 	usersGroup.Get("", userController.FindAll)
-	usersGroup.Put("/:id", userController.Update)
-	usersGroup.Delete("/:id", userController.Delete)
+	usersGroup.Put("/me", middleware.RefuseAPIKey(), userController.Update)
+	usersGroup.Put("/:id", middleware.RequirePermission(domain.PermissionUsersUpdate), userController.Update)
+	usersGroup.Delete("/me", middleware.RefuseAPIKey(), userController.Delete)
+	usersGroup.Put("/me/password", middleware.RefuseAPIKey(), authController.ChangePassword)
+	usersGroup.Put("/me/email", middleware.RefuseAPIKey(), userController.ChangeEmail)
+	usersGroup.Post("/me/email/verify", middleware.RefuseAPIKey(), userController.VerifyEmailChange)
+	usersGroup.Delete("/:id", middleware.RequirePermission(domain.PermissionUsersDelete), userController.Delete)
+	usersGroup.Post("/:id/revoke", middleware.RequirePermission(domain.PermissionUsersRevoke), authController.RevokeSessions)
+	usersGroup.Put("/:id/roles", middleware.RequirePermission(domain.PermissionRolesAssign), userController.SetRoles)
+
+	// API Key Controller
+	usersGroup.Get("/me/tokens", middleware.RefuseAPIKey(), apiKeyController.FindAll)
+	usersGroup.Post("/me/tokens", middleware.RefuseAPIKey(), apiKeyController.Create)
+	usersGroup.Delete("/me/tokens/:id", middleware.RefuseAPIKey(), apiKeyController.Delete)
+
+	// MFA Controller
+	usersGroup.Post("/me/mfa", middleware.RefuseAPIKey(), mfaController.Enroll)
+	usersGroup.Post("/me/mfa/confirm", middleware.RefuseAPIKey(), mfaController.Confirm)
+	usersGroup.Delete("/me/mfa", middleware.RefuseAPIKey(), mfaController.Disable)
+
+	// Role Controller
+	rolesGroup := app.Core.Group("/roles", middleware.RequirePermission(domain.PermissionRolesManage))
+	rolesGroup.Get("", roleController.FindAll)
+	rolesGroup.Get("/:id", roleController.FindById)
+	rolesGroup.Put("/:id", roleController.Put)
+	rolesGroup.Delete("/:id", roleController.Delete)
 
 	// Stack Controller
 	stacksGroup := app.Core.Group("/stacks")
@@ -92,6 +209,46 @@ func (app *App) SetupRouter(
 	stacksGroup.Get("", stackController.FindAll)
 	stacksGroup.Post("/:id", stackController.Push)
 	stacksGroup.Delete("/:id", stackController.Pop)
+	stacksGroup.Delete("/:id/", stackController.Delete)
+	stacksGroup.Get("/:id/top", stackController.Top)
+	stacksGroup.Get("/:id/size", stackController.Size)
+	stacksGroup.Get("/:id/items", stackController.Items)
+	stacksGroup.Post("/:id/items", stackController.PushMany)
+	stacksGroup.Delete("/:id/items", stackController.Clear)
+	stacksGroup.Put("/:id/schema", stackController.SetSchema)
+
+	// Queue Controller
+	queuesGroup := app.Core.Group("/queues")
+	queuesGroup.Post("", queueController.Create)
+	queuesGroup.Get("/:id", queueController.FindById)
+	queuesGroup.Get("", queueController.FindAll)
+	queuesGroup.Post("/:id", queueController.Enqueue)
+	queuesGroup.Delete("/:id", queueController.Dequeue)
+	queuesGroup.Get("/:id/front", queueController.Peek)
+
+	// Tree Controller
+	treesGroup := app.Core.Group("/trees")
+	treesGroup.Post("", treeController.Create)
+	treesGroup.Get("/:id", treeController.FindById)
+	treesGroup.Get("", treeController.FindAll)
+	treesGroup.Post("/:id/nodes", treeController.Insert)
+	treesGroup.Get("/:id/nodes/:key", treeController.Contains)
+	treesGroup.Delete("/:id/nodes/:key", treeController.Delete)
+	treesGroup.Get("/:id/nodes/:key/successor", treeController.Successor)
+	treesGroup.Get("/:id/nodes/:key/predecessor", treeController.Predecessor)
+	treesGroup.Get("/:id/min", treeController.Min)
+	treesGroup.Get("/:id/max", treeController.Max)
+	treesGroup.Get("/:id/traversals/:order", treeController.Traverse)
+
+	// Ordered Map Controller
+	mapsGroup := app.Core.Group("/maps")
+	mapsGroup.Post("", orderedMapController.Create)
+	mapsGroup.Get("/:id", orderedMapController.FindById)
+	mapsGroup.Get("", orderedMapController.FindAll)
+	mapsGroup.Get("/:id/entries", orderedMapController.Entries)
+	mapsGroup.Put("/:id/entries/:key", orderedMapController.Put)
+	mapsGroup.Get("/:id/entries/:key", orderedMapController.Get)
+	mapsGroup.Delete("/:id/entries/:key", orderedMapController.Delete)
 
 	// Docs Controller
 	docsGroup := app.Core.Group("/docs")
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyController interface {
+	Create(*fiber.Ctx) error
+	FindAll(*fiber.Ctx) error
+	Delete(*fiber.Ctx) error
+}
+
+type APIKeyControllerImpl struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) APIKeyController {
+	apiKeyController := new(APIKeyControllerImpl)
+	apiKeyController.apiKeyService = apiKeyService
+
+	return apiKeyController
+}
+
+// API keys can only be created when signed in, a key cannot create other keys
+func (controller *APIKeyControllerImpl) Create(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+	if authResponse.APIKeyID != "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+	}
+
+	request := web.APIKeyCreateRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.apiKeyService.Create(authResponse.ID, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *APIKeyControllerImpl) FindAll(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	apiKeys, err := controller.apiKeyService.FindAllFromOwner(authResponse.ID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(web.NewFailPayload(http.StatusInternalServerError))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    apiKeys,
+	})
+}
+
+func (controller *APIKeyControllerImpl) Delete(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.apiKeyService.DeleteFromOwner(id, authResponse.ID); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"godas/model/web"
+	"godas/secure"
 	"godas/service"
 	"log"
 	"net/http"
@@ -12,6 +14,14 @@ import (
 
 type AuthController interface {
 	Signin(*fiber.Ctx) error
+	SigninMFA(*fiber.Ctx) error
+	Refresh(*fiber.Ctx) error
+	Signout(*fiber.Ctx) error
+	RevokeSessions(*fiber.Ctx) error
+	ChangePassword(*fiber.Ctx) error
+	ForgotPassword(*fiber.Ctx) error
+	ResetPassword(*fiber.Ctx) error
+	JWKS(*fiber.Ctx) error
 	Signup(*fiber.Ctx) error
 	EmailVerification(*fiber.Ctx) error
 	ResendEmailVerification(*fiber.Ctx) error
@@ -36,12 +46,15 @@ func (controller AuthControllerImpl) Signin(ctx *fiber.Ctx) error {
 	if err := ctx.BodyParser(&request); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
+	request.IP = ctx.IP()
 
 	token, err := controller.authService.Signin(request)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrUnauthorized) {
 			statusCode = http.StatusUnauthorized
+		} else if errors.Is(err, service.ErrTooManyRequests) {
+			statusCode = http.StatusTooManyRequests
 		}
 
 		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
@@ -55,6 +68,206 @@ func (controller AuthControllerImpl) Signin(ctx *fiber.Ctx) error {
 	})
 }
 
+func (controller AuthControllerImpl) SigninMFA(ctx *fiber.Ctx) error {
+	request := web.MFASigninRequest{}
+
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	token, err := controller.authService.SigninMFA(request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		} else if errors.Is(err, service.ErrTooManyRequests) {
+			statusCode = http.StatusTooManyRequests
+		}
+
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    token,
+	})
+}
+
+func (controller AuthControllerImpl) Refresh(ctx *fiber.Ctx) error {
+	request := web.RefreshRequest{}
+
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	token, err := controller.authService.Refresh(request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    token,
+	})
+}
+
+func (controller AuthControllerImpl) Signout(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	// The body is optional, without a refresh token only the access token is revoked
+	request := web.SignoutRequest{}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&request); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+		}
+	}
+
+	if err := controller.authService.Signout(authResponse, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller AuthControllerImpl) RevokeSessions(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.authService.RevokeSessions(id); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller AuthControllerImpl) ChangePassword(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.PasswordChangeRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.authService.ChangePassword(authResponse, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller AuthControllerImpl) ForgotPassword(ctx *fiber.Ctx) error {
+	request := web.PasswordForgotRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.authService.ForgotPassword(request); err != nil {
+		log.Println(err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller AuthControllerImpl) ResetPassword(ctx *fiber.Ctx) error {
+	request := web.PasswordResetRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.authService.ResetPassword(request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+// The key set is served as is, clients expect the JWKS document at the top level
+func (controller AuthControllerImpl) JWKS(ctx *fiber.Ctx) error {
+	response, err := controller.authService.JWKS()
+	if err != nil {
+		log.Println(err)
+		return ctx.Status(http.StatusInternalServerError).JSON(web.NewFailPayload(http.StatusInternalServerError))
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(secure.JWKSMaxAge.Seconds())))
+	return ctx.JSON(response)
+}
+
 func (controller AuthControllerImpl) Signup(ctx *fiber.Ctx) error {
 	userCreateRequest := web.UserCreateRequest{}
 	if err := ctx.BodyParser(&userCreateRequest); err != nil {
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MFAController interface {
+	Enroll(*fiber.Ctx) error
+	Confirm(*fiber.Ctx) error
+	Disable(*fiber.Ctx) error
+}
+
+type MFAControllerImpl struct {
+	mfaService service.MFAService
+}
+
+func NewMFAController(mfaService service.MFAService) MFAController {
+	mfaController := new(MFAControllerImpl)
+	mfaController.mfaService = mfaService
+
+	return mfaController
+}
+
+// Two-factor authentication can only be changed when signed in, not with an API key
+func (controller *MFAControllerImpl) Enroll(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+	if authResponse.APIKeyID != "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+	}
+
+	response, err := controller.mfaService.Enroll(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *MFAControllerImpl) Confirm(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+	if authResponse.APIKeyID != "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+	}
+
+	request := web.MFAConfirmRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.mfaService.Confirm(authResponse.ID, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller *MFAControllerImpl) Disable(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+	if authResponse.APIKeyID != "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+	}
+
+	request := web.MFADisableRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.mfaService.Disable(authResponse.ID, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
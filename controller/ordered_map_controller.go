@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrderedMapController interface {
+	Create(ctx *fiber.Ctx) error
+	FindById(ctx *fiber.Ctx) error
+	FindAll(ctx *fiber.Ctx) error
+	Put(ctx *fiber.Ctx) error
+	Get(ctx *fiber.Ctx) error
+	Delete(ctx *fiber.Ctx) error
+	Entries(ctx *fiber.Ctx) error
+}
+
+type OrderedMapControllerImpl struct {
+	orderedMapService service.OrderedMapService
+}
+
+func NewOrderedMapController(orderedMapService service.OrderedMapService) OrderedMapController {
+	controller := new(OrderedMapControllerImpl)
+	controller.orderedMapService = orderedMapService
+
+	return controller
+}
+
+func (controller *OrderedMapControllerImpl) Create(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.OrderedMapCreateRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.orderedMapService.Create(authResponse.ID, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) FindById(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	orderedMap, err := controller.orderedMapService.FindByIdFromOwner(id, authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    orderedMap,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) FindAll(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	orderedMaps, err := controller.orderedMapService.FindAllFromOwner(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    orderedMaps,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) Put(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	key, err := strconv.ParseInt(ctx.Params("key"), 10, 64)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.OrderedMapEntryRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.orderedMapService.PutFromOwner(id, authResponse.ID, key, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrConflict) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) Get(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	key, err := strconv.ParseInt(ctx.Params("key"), 10, 64)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.orderedMapService.GetFromOwner(id, authResponse.ID, key)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) Delete(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	key, err := strconv.ParseInt(ctx.Params("key"), 10, 64)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.orderedMapService.DeleteFromOwner(id, authResponse.ID, key)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrConflict) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *OrderedMapControllerImpl) Entries(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	entries, err := controller.orderedMapService.EntriesFromOwner(id, authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    entries,
+	})
+}
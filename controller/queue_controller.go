@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type QueueController interface {
+	Create(ctx *fiber.Ctx) error
+	FindById(ctx *fiber.Ctx) error
+	FindAll(ctx *fiber.Ctx) error
+	Enqueue(ctx *fiber.Ctx) error
+	Dequeue(ctx *fiber.Ctx) error
+	Peek(ctx *fiber.Ctx) error
+}
+
+type QueueControllerImpl struct {
+	queueService service.QueueService
+}
+
+func NewQueueController(queueService service.QueueService) QueueController {
+	controller := new(QueueControllerImpl)
+	controller.queueService = queueService
+
+	return controller
+}
+
+func (controller *QueueControllerImpl) Create(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.queueService.Create(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *QueueControllerImpl) FindById(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	queue, err := controller.queueService.FindByIdFromOwner(id, authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    queue,
+	})
+}
+
+func (controller *QueueControllerImpl) FindAll(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	queues, err := controller.queueService.FindAllFromOwner(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    queues,
+	})
+}
+
+func (controller *QueueControllerImpl) Enqueue(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.ItemRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.queueService.EnqueueFromOwner(id, authResponse.ID, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *QueueControllerImpl) Dequeue(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.queueService.DequeueFromOwner(id, authResponse.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrEmpty) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeEmpty))
+		}
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *QueueControllerImpl) Peek(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.queueService.PeekFromOwner(id, authResponse.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrEmpty) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeEmpty))
+		}
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
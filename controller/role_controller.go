@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleController interface {
+	FindById(*fiber.Ctx) error
+	FindAll(*fiber.Ctx) error
+	Put(*fiber.Ctx) error
+	Delete(*fiber.Ctx) error
+}
+
+type RoleControllerImpl struct {
+	roleService service.RoleService
+}
+
+func NewRoleController(roleService service.RoleService) RoleController {
+	roleController := new(RoleControllerImpl)
+	roleController.roleService = roleService
+
+	return roleController
+}
+
+func (controller *RoleControllerImpl) FindById(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	role, err := controller.roleService.FindById(id)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    role,
+	})
+}
+
+func (controller *RoleControllerImpl) FindAll(ctx *fiber.Ctx) error {
+	roles, err := controller.roleService.FindAll()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(web.NewFailPayload(http.StatusInternalServerError))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    roles,
+	})
+}
+
+func (controller *RoleControllerImpl) Put(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.RoleRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	role, err := controller.roleService.Put(authResponse, id, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrForbidden) {
+			statusCode = http.StatusForbidden
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    role,
+	})
+}
+
+func (controller *RoleControllerImpl) Delete(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.roleService.Delete(id); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"errors"
+	"godas/model/domain"
 	"godas/model/web"
 	"godas/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -14,7 +16,14 @@ type StackController interface {
 	FindById(ctx *fiber.Ctx) error
 	FindAll(ctx *fiber.Ctx) error
 	Push(ctx *fiber.Ctx) error
+	PushMany(ctx *fiber.Ctx) error
 	Pop(ctx *fiber.Ctx) error
+	Top(ctx *fiber.Ctx) error
+	Size(ctx *fiber.Ctx) error
+	Items(ctx *fiber.Ctx) error
+	Clear(ctx *fiber.Ctx) error
+	Delete(ctx *fiber.Ctx) error
+	SetSchema(ctx *fiber.Ctx) error
 }
 
 type StackControllerImpl struct {
@@ -34,10 +43,20 @@ func (controller *StackControllerImpl) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	response, err := controller.stackService.Create(authResponse.ID)
+	// The body is optional, a stack can be created without any metadata
+	request := web.StackCreateRequest{}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&request); err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+		}
+	}
+
+	response, err := controller.stackService.Create(authResponse.ID, request)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if errors.Is(err, service.ErrDuplicate) {
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrDuplicate) {
 			statusCode = http.StatusConflict
 		}
 		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
@@ -62,7 +81,13 @@ func (controller *StackControllerImpl) FindById(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	stack, err := controller.stackService.FindByIdFromOwner(id, authResponse.ID)
+	var stack web.StackResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksReadAny) {
+		stack, err = controller.stackService.FindById(id)
+	} else {
+		stack, err = controller.stackService.FindByIdFromOwner(id, authResponse.ID)
+	}
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, service.ErrNotFound) {
@@ -85,10 +110,23 @@ func (controller *StackControllerImpl) FindAll(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	stacks, err := controller.stackService.FindAllFromOwner(authResponse.ID)
+	request := web.StackFilterRequest{}
+	if err := ctx.QueryParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var stacks web.StackPageResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksReadAny) {
+		stacks, err = controller.stackService.FindAll(request)
+	} else {
+		stacks, err = controller.stackService.FindAllFromOwner(authResponse.ID, request)
+	}
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if errors.Is(err, service.ErrNotFound) {
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
 			statusCode = http.StatusNotFound
 		}
 		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
@@ -118,8 +156,61 @@ func (controller *StackControllerImpl) Push(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	response, err := controller.stackService.PushFromOwner(id, authResponse.ID, request)
+	var response web.ItemResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksWriteAny) {
+		response, err = controller.stackService.Push(ctx.Context(), id, request)
+	} else {
+		response, err = controller.stackService.PushFromOwner(ctx.Context(), id, authResponse.ID, request)
+	}
 	if err != nil {
+		if errors.Is(err, service.ErrFull) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeFull))
+		}
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *StackControllerImpl) PushMany(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	requests := []web.ItemRequest{}
+	if err := ctx.BodyParser(&requests); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var response []web.ItemResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksWriteAny) {
+		response, err = controller.stackService.PushMany(ctx.Context(), id, requests)
+	} else {
+		response, err = controller.stackService.PushManyFromOwner(ctx.Context(), id, authResponse.ID, requests)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrFull) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeFull))
+		}
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, service.ErrBadRequest) {
 			statusCode = http.StatusBadRequest
@@ -148,7 +239,209 @@ func (controller *StackControllerImpl) Pop(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	response, err := controller.stackService.PopFromOwner(id, authResponse.ID)
+	var response any
+	var err error
+	anyOwner := authResponse.Can(domain.PermissionStacksWriteAny)
+	if ctx.Query("count") == "" {
+		if anyOwner {
+			response, err = controller.stackService.Pop(id)
+		} else {
+			response, err = controller.stackService.PopFromOwner(id, authResponse.ID)
+		}
+	} else {
+		count, parseErr := strconv.Atoi(ctx.Query("count"))
+		if parseErr != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+		}
+		if anyOwner {
+			response, err = controller.stackService.PopMany(id, count)
+		} else {
+			response, err = controller.stackService.PopManyFromOwner(id, authResponse.ID, count)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrEmpty) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeEmpty))
+		}
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *StackControllerImpl) Top(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var response web.ItemResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksReadAny) {
+		response, err = controller.stackService.Top(id)
+	} else {
+		response, err = controller.stackService.TopFromOwner(id, authResponse.ID)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrEmpty) {
+			return ctx.Status(http.StatusConflict).JSON(web.NewErrorPayload(http.StatusConflict, web.ErrorCodeEmpty))
+		}
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *StackControllerImpl) Size(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var response web.StackSizeResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksReadAny) {
+		response, err = controller.stackService.Size(id)
+	} else {
+		response, err = controller.stackService.SizeFromOwner(id, authResponse.ID)
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *StackControllerImpl) Items(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.StackItemsRequest{}
+	if err := ctx.QueryParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var response web.StackItemsResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksReadAny) {
+		response, err = controller.stackService.Items(id, request)
+	} else {
+		response, err = controller.stackService.ItemsFromOwner(id, authResponse.ID, request)
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *StackControllerImpl) Clear(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var err error
+	if authResponse.Can(domain.PermissionStacksWriteAny) {
+		err = controller.stackService.Clear(id)
+	} else {
+		err = controller.stackService.ClearFromOwner(id, authResponse.ID)
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller *StackControllerImpl) Delete(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var err error
+	if authResponse.Can(domain.PermissionStacksWriteAny) {
+		err = controller.stackService.Delete(id)
+	} else {
+		err = controller.stackService.DeleteFromOwner(id, authResponse.ID)
+	}
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, service.ErrNotFound) {
@@ -157,6 +450,47 @@ func (controller *StackControllerImpl) Pop(ctx *fiber.Ctx) error {
 		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
 	}
 
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller *StackControllerImpl) SetSchema(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.StackSchemaRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	var response web.StackResponse
+	var err error
+	if authResponse.Can(domain.PermissionStacksWriteAny) {
+		response, err = controller.stackService.SetSchema(id, request)
+	} else {
+		response, err = controller.stackService.SetSchemaFromOwner(id, authResponse.ID, request)
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
 	return ctx.JSON(web.Payload{
 		Code:    http.StatusOK,
 		Status:  http.StatusText(http.StatusOK),
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TreeController interface {
+	Create(ctx *fiber.Ctx) error
+	FindById(ctx *fiber.Ctx) error
+	FindAll(ctx *fiber.Ctx) error
+	Insert(ctx *fiber.Ctx) error
+	Delete(ctx *fiber.Ctx) error
+	Contains(ctx *fiber.Ctx) error
+	Min(ctx *fiber.Ctx) error
+	Max(ctx *fiber.Ctx) error
+	Successor(ctx *fiber.Ctx) error
+	Predecessor(ctx *fiber.Ctx) error
+	Traverse(ctx *fiber.Ctx) error
+}
+
+type TreeControllerImpl struct {
+	treeService service.TreeService
+}
+
+func NewTreeController(treeService service.TreeService) TreeController {
+	controller := new(TreeControllerImpl)
+	controller.treeService = treeService
+
+	return controller
+}
+
+func (controller *TreeControllerImpl) Create(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.treeService.Create(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *TreeControllerImpl) FindById(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	tree, err := controller.treeService.FindByIdFromOwner(id, authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    tree,
+	})
+}
+
+func (controller *TreeControllerImpl) FindAll(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	trees, err := controller.treeService.FindAllFromOwner(authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    trees,
+	})
+}
+
+func (controller *TreeControllerImpl) Insert(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.TreeNodeRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.treeService.InsertFromOwner(id, authResponse.ID, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrDuplicate) || errors.Is(err, service.ErrConflict) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *TreeControllerImpl) Delete(ctx *fiber.Ctx) error {
+	return controller.keyLookup(ctx, controller.treeService.DeleteFromOwner)
+}
+
+func (controller *TreeControllerImpl) Contains(ctx *fiber.Ctx) error {
+	return controller.keyLookup(ctx, controller.treeService.ContainsFromOwner)
+}
+
+func (controller *TreeControllerImpl) Min(ctx *fiber.Ctx) error {
+	return controller.lookup(ctx, controller.treeService.MinFromOwner)
+}
+
+func (controller *TreeControllerImpl) Max(ctx *fiber.Ctx) error {
+	return controller.lookup(ctx, controller.treeService.MaxFromOwner)
+}
+
+func (controller *TreeControllerImpl) Successor(ctx *fiber.Ctx) error {
+	return controller.keyLookup(ctx, controller.treeService.SuccessorFromOwner)
+}
+
+func (controller *TreeControllerImpl) Predecessor(ctx *fiber.Ctx) error {
+	return controller.keyLookup(ctx, controller.treeService.PredecessorFromOwner)
+}
+
+func (controller *TreeControllerImpl) Traverse(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := controller.treeService.TraverseFromOwner(id, authResponse.ID, domain.TraversalOrder(ctx.Params("order")))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *TreeControllerImpl) lookup(ctx *fiber.Ctx, lookup func(id string, owner string) (web.TreeNodeResponse, error)) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	id := ctx.Params("id")
+	if id == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	response, err := lookup(id, authResponse.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrConflict) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    response,
+	})
+}
+
+func (controller *TreeControllerImpl) keyLookup(ctx *fiber.Ctx, lookup func(id string, owner string, key int64) (web.TreeNodeResponse, error)) error {
+	key, err := strconv.ParseInt(ctx.Params("key"), 10, 64)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	return controller.lookup(ctx, func(id string, owner string) (web.TreeNodeResponse, error) {
+		return lookup(id, owner, key)
+	})
+}
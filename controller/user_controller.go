@@ -2,7 +2,6 @@ package controller
 
 import (
 	"errors"
-	"godas/model/domain"
 	"godas/model/web"
 	"godas/service"
 	"net/http"
@@ -15,6 +14,9 @@ type UserController interface {
 	FindById(*fiber.Ctx) error
 	FindAll(*fiber.Ctx) error
 	Update(*fiber.Ctx) error
+	SetRoles(*fiber.Ctx) error
+	ChangeEmail(*fiber.Ctx) error
+	VerifyEmailChange(*fiber.Ctx) error
 	Delete(*fiber.Ctx) error
 }
 
@@ -30,15 +32,6 @@ func NewUserController(service service.UserService) UserController {
 }
 
 func (controller *UserControllerImpl) Create(ctx *fiber.Ctx) error {
-	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
-	if !isAuthResponse {
-		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
-	}
-
-	if authResponse.Role != domain.UserRoleAdmin {
-		return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
-	}
-
 	userCreateRequest := web.UserCreateRequest{}
 	if err := ctx.BodyParser(&userCreateRequest); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
@@ -113,16 +106,8 @@ func (controller *UserControllerImpl) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
-	id := ctx.Params("id")
-	if id == "" {
-		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
-	} else if id == "me" {
-		id = authResponse.ID
-	} else {
-		if authResponse.Role != domain.UserRoleAdmin {
-			return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
-		}
-	}
+	// Routes under /users/me have no id and act on the user of the request
+	id := ctx.Params("id", authResponse.ID)
 
 	request := web.UserUpdateRequest{}
 	if err := ctx.BodyParser(&request); err != nil {
@@ -146,7 +131,7 @@ func (controller *UserControllerImpl) Update(ctx *fiber.Ctx) error {
 	})
 }
 
-func (controller *UserControllerImpl) Delete(ctx *fiber.Ctx) error {
+func (controller *UserControllerImpl) SetRoles(ctx *fiber.Ctx) error {
 	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
 	if !isAuthResponse {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
@@ -155,14 +140,42 @@ func (controller *UserControllerImpl) Delete(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 	if id == "" {
 		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
-	} else if id == "me" {
-		id = authResponse.ID
-	} else {
-		if authResponse.Role != domain.UserRoleAdmin {
-			return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+	}
+
+	request := web.UserRolesRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.service.SetRoles(authResponse, id, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrForbidden) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
 		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller *UserControllerImpl) Delete(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
 	}
 
+	// Routes under /users/me have no id and act on the user of the request
+	id := ctx.Params("id", authResponse.ID)
+
 	if err := controller.service.Delete(id); err != nil {
 		statusCode := http.StatusInternalServerError
 		if errors.Is(err, service.ErrNotFound) {
@@ -178,3 +191,70 @@ func (controller *UserControllerImpl) Delete(ctx *fiber.Ctx) error {
 		Data:    nil,
 	})
 }
+
+func (controller *UserControllerImpl) ChangeEmail(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.EmailChangeRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	if err := controller.service.ChangeEmail(authResponse.ID, request); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		} else if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    nil,
+	})
+}
+
+func (controller *UserControllerImpl) VerifyEmailChange(ctx *fiber.Ctx) error {
+	authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+	if !isAuthResponse {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	request := web.EmailVerificationCreateRequest{}
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(web.NewFailPayload(http.StatusBadRequest))
+	}
+
+	user, err := controller.service.VerifyEmailChange(authResponse.ID, request)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrBadRequest) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrUnauthorized) {
+			statusCode = http.StatusUnauthorized
+		} else if errors.Is(err, service.ErrDuplicate) {
+			statusCode = http.StatusConflict
+		}
+		return ctx.Status(statusCode).JSON(web.NewFailPayload(statusCode))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(web.Payload{
+		Code:    http.StatusOK,
+		Status:  http.StatusText(http.StatusOK),
+		Success: true,
+		Data:    user,
+	})
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Writes every message to its own .eml file, for development without a mail server
+type FileMailer struct {
+	directory string
+}
+
+func NewFileMailer(directory string) (*FileMailer, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	fileMailer := new(FileMailer)
+	fileMailer.directory = directory
+
+	return fileMailer, nil
+}
+
+// Files are named after the time they were written so that they list in order
+func (mailer *FileMailer) Send(message Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000Z"), hex.EncodeToString(suffix))
+
+	return os.WriteFile(filepath.Join(mailer.directory, name), message.Bytes(), 0o600)
+}
@@ -0,0 +1,93 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Supported transports, SMTP sends through a mail server, file writes .eml files to a directory
+// and memory keeps the messages for tests
+const (
+	TransportSMTP   = "smtp"
+	TransportFile   = "file"
+	TransportMemory = "memory"
+)
+
+var ErrUnknownTransport = errors.New("unknown mail transport")
+
+// Sends plain text messages
+type Mailer interface {
+	Send(Message) error
+}
+
+// Date and ID are set when the message is encoded unless they are given
+type Message struct {
+	From    mail.Address
+	To      mail.Address
+	Subject string
+	Body    string
+	Date    time.Time
+	ID      string
+}
+
+// Encode the message in the Internet Message Format of RFC 5322, the body is base64 encoded UTF-8
+func (message Message) Bytes() []byte {
+	buffer := new(bytes.Buffer)
+
+	if message.Date.IsZero() {
+		message.Date = time.Now()
+	}
+	if message.ID == "" {
+		message.ID = newMessageID(message.From.Address)
+	}
+
+	// Headers are written in a fixed order so that the same message is always encoded the same way
+	headers := [][2]string{
+		{"Date", message.Date.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + message.ID + ">"},
+		{"From", message.From.String()},
+		{"To", message.To.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=\"utf-8\""},
+		{"Content-Transfer-Encoding", "base64"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(buffer, "%s: %s\r\n", header[0], header[1])
+	}
+	buffer.WriteString("\r\n")
+
+	// Lines of the body are at most 76 characters long, as required by RFC 2045
+	body := base64.StdEncoding.EncodeToString([]byte(message.Body))
+	for len(body) > 76 {
+		buffer.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buffer.WriteString(body + "\r\n")
+
+	return buffer.Bytes()
+}
+
+// A random id on the domain of the sender, as recommended by RFC 5322
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		binary.BigEndian.PutUint64(id, uint64(time.Now().UnixNano()))
+	}
+
+	return hex.EncodeToString(id) + "@" + domain
+}
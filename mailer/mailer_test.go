@@ -0,0 +1,111 @@
+package mailer_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"godas/mailer"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageBytes(t *testing.T) {
+	message := mailer.Message{
+		From:    mail.Address{Name: "App", Address: "app@example.com"},
+		To:      mail.Address{Address: "a@example.com"},
+		Subject: "Vérification",
+		Body:    strings.Repeat("code ", 40),
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(message.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != message.Subject {
+		t.Fatalf("expected the subject %q, got %q", message.Subject, subject)
+	}
+	if parsed.Header.Get("From") != `"App" <app@example.com>` || parsed.Header.Get("To") != "<a@example.com>" {
+		t.Fatalf("expected the addresses to be kept, got %v", parsed.Header)
+	}
+
+	// Relays expect every message to have a date and an id
+	if date, err := parsed.Header.Date(); err != nil || time.Since(date) > time.Minute {
+		t.Fatalf("expected the message to be dated now, got %q (%v)", parsed.Header.Get("Date"), err)
+	}
+	id := parsed.Header.Get("Message-ID")
+	if !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.com>") {
+		t.Fatalf("expected an id on the domain of the sender, got %q", id)
+	}
+	if other, _ := mail.ReadMessage(bytes.NewReader(message.Bytes())); other.Header.Get("Message-ID") == id {
+		t.Fatal("expected every message to get its own id")
+	}
+
+	body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, parsed.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != message.Body {
+		t.Fatalf("expected the body %q, got %q", message.Body, body)
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	memoryMailer := mailer.NewMemoryMailer()
+	for _, subject := range []string{"first", "second"} {
+		if err := memoryMailer.Send(mailer.Message{Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := memoryMailer.Messages()
+	if len(messages) != 2 || messages[0].Subject != "first" || messages[1].Subject != "second" {
+		t.Fatalf("expected both messages in order, got %+v", messages)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mail")
+	fileMailer, err := mailer.NewFileMailer(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := mailer.Message{
+		From:    mail.Address{Address: "app@example.com"},
+		To:      mail.Address{Address: "a@example.com"},
+		Subject: "Email Verification",
+		Body:    "ABC123",
+		Date:    time.Now(),
+		ID:      "1@example.com",
+	}
+	for i := 0; i < 2; i++ {
+		if err := fileMailer.Send(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(directory, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected a file per message, got %v", files)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, message.Bytes()) {
+		t.Fatalf("expected the encoded message, got %q", content)
+	}
+}
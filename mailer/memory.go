@@ -0,0 +1,30 @@
+package mailer
+
+import "sync"
+
+// Keeps sent messages in memory, for tests
+type MemoryMailer struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return new(MemoryMailer)
+}
+
+func (mailer *MemoryMailer) Send(message Message) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.messages = append(mailer.messages, message)
+
+	return nil
+}
+
+// Messages sent so far, oldest first
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return append([]Message{}, mailer.messages...)
+}
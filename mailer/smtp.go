@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// How the connection to the mail server is secured, STARTTLS upgrades a plain connection
+// (usually on port 587) and TLS connects with TLS from the start (usually on port 465)
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+)
+
+const smtpTimeout = time.Second * 30
+
+type SMTPMailer struct {
+	host      string
+	port      string
+	username  string
+	password  string
+	security  string
+	tlsConfig *tls.Config
+}
+
+// The connection is always encrypted, messages are never sent to a server that does not support STARTTLS.
+// No authentication is made when the username is empty.
+func NewSMTPMailer(host string, port string, username string, password string, security string) (*SMTPMailer, error) {
+	if security == "" {
+		security = SMTPSecurityStartTLS
+	}
+	if security != SMTPSecurityStartTLS && security != SMTPSecurityTLS {
+		return nil, fmt.Errorf("unknown SMTP security: %s", security)
+	}
+
+	smtpMailer := new(SMTPMailer)
+	smtpMailer.host = host
+	smtpMailer.port = port
+	smtpMailer.username = username
+	smtpMailer.password = password
+	smtpMailer.security = security
+	smtpMailer.tlsConfig = &tls.Config{ServerName: host}
+
+	return smtpMailer, nil
+}
+
+func (mailer *SMTPMailer) Send(message Message) error {
+	address := net.JoinHostPort(mailer.host, mailer.port)
+
+	var connection net.Conn
+	var err error
+	if mailer.security == SMTPSecurityTLS {
+		connection, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", address, mailer.tlsConfig)
+	} else {
+		connection, err = net.DialTimeout("tcp", address, smtpTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	connection.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(connection, mailer.host)
+	if err != nil {
+		connection.Close()
+		return err
+	}
+	defer client.Close()
+
+	if mailer.security == SMTPSecurityStartTLS {
+		if supported, _ := client.Extension("STARTTLS"); !supported {
+			return fmt.Errorf("%s does not support STARTTLS", address)
+		}
+		if err := client.StartTLS(mailer.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if mailer.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(message.From.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To.Address); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message.Bytes()); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
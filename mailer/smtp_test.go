@@ -0,0 +1,196 @@
+package mailer
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Minimal SMTP server that records the session of a single message
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool
+	startTLS  bool
+	received  chan fakeSMTPSession
+}
+
+type fakeSMTPSession struct {
+	tls  bool
+	auth string
+	from string
+	to   string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T, implicit bool, startTLS bool) (*fakeSMTPServer, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTPServer{
+		listener:  listener,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		implicit:  implicit,
+		startTLS:  startTLS,
+		received:  make(chan fakeSMTPSession, 1),
+	}
+	go server.serve()
+
+	return server, pool
+}
+
+func (server *fakeSMTPServer) serve() {
+	connection, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer connection.Close()
+
+	session := fakeSMTPSession{}
+	if server.implicit {
+		connection = tls.Server(connection, server.tlsConfig)
+		session.tls = true
+	}
+	text := textproto.NewConn(connection)
+	text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			if server.startTLS && !session.tls {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			text.PrintfLine("220 ready")
+			connection = tls.Server(connection, server.tlsConfig)
+			text = textproto.NewConn(connection)
+			session.tls = true
+		case "AUTH":
+			session.auth = strings.TrimPrefix(line, "AUTH PLAIN ")
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			session.from = line
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			session.to = line
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			session.data = string(data)
+			text.PrintfLine("250 ok")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			server.received <- session
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (server *fakeSMTPServer) mailer(t *testing.T, security string, pool *x509.CertPool) *SMTPMailer {
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	smtpMailer, err := NewSMTPMailer(host, port, "app@example.com", "password", security)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smtpMailer.tlsConfig.RootCAs = pool
+
+	return smtpMailer
+}
+
+func TestSMTPMailer(t *testing.T) {
+	message := Message{
+		From:    mail.Address{Name: "App", Address: "app@example.com"},
+		To:      mail.Address{Address: "a@example.com"},
+		Subject: "Email Verification",
+		Body:    "ABC123",
+	}
+
+	for _, security := range []string{SMTPSecurityStartTLS, SMTPSecurityTLS} {
+		server, pool := newFakeSMTPServer(t, security == SMTPSecurityTLS, security == SMTPSecurityStartTLS)
+		if err := server.mailer(t, security, pool).Send(message); err != nil {
+			t.Fatalf("%s: %v", security, err)
+		}
+
+		session := <-server.received
+		if !session.tls {
+			t.Fatalf("%s: expected the message to be sent over TLS", security)
+		}
+		auth, err := base64.StdEncoding.DecodeString(session.auth)
+		if err != nil || string(auth) != "\x00app@example.com\x00password" {
+			t.Fatalf("%s: expected to authenticate, got %q", security, auth)
+		}
+		if session.from != "MAIL FROM:<app@example.com>" || !strings.HasPrefix(session.to, "RCPT TO:<a@example.com>") {
+			t.Fatalf("%s: expected the envelope of the message, got %q and %q", security, session.from, session.to)
+		}
+		reader := bufio.NewReader(strings.NewReader(session.data))
+		if parsed, err := mail.ReadMessage(reader); err != nil || parsed.Header.Get("Subject") != "Email Verification" {
+			t.Fatalf("%s: expected the message, got %q", security, session.data)
+		}
+	}
+}
+
+func TestSMTPMailerRequiresSTARTTLS(t *testing.T) {
+	server, pool := newFakeSMTPServer(t, false, false)
+	err := server.mailer(t, SMTPSecurityStartTLS, pool).Send(Message{
+		From: mail.Address{Address: "app@example.com"},
+		To:   mail.Address{Address: "a@example.com"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected a server without STARTTLS to be refused, got %v", err)
+	}
+}
@@ -18,23 +18,50 @@ func main() {
 	mainApp := app.New(test)
 
 	emailVerificationRepository := repository.NewEmailVerificationRepository(mainApp.DB)
-	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, mainApp.Validate)
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, mainApp.Mailer, mainApp.Validate, mainApp.VerificationCodeLength, mainApp.VerificationLinkURL)
 
-	userRepository := repository.NewUserRepository(mainApp.DB, mainApp.SnowflakeNode)
-	userService := service.NewUserService(userRepository, emailVerificationService, mainApp.Validate)
+	userRepository := repository.NewCachedUserRepository(repository.NewUserRepository(mainApp.DB, mainApp.SnowflakeNode), mainApp.UserCacheTTL)
+	roleRepository := repository.NewCachedRoleRepository(repository.NewRoleRepository(mainApp.DB), mainApp.UserCacheTTL)
+	roleService := service.NewRoleService(roleRepository, mainApp.Validate)
+	roleController := controller.NewRoleController(roleService)
+
+	userService := service.NewUserService(userRepository, roleRepository, emailVerificationService, mainApp.Validate, mainApp.PasswordHasher)
 	userController := controller.NewUserController(userService)
 
-	authService := service.NewAuthService(userRepository, mainApp.JWTProvider)
+	apiKeyRepository := repository.NewAPIKeyRepository(mainApp.DB, mainApp.SnowflakeNode)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, mainApp.Validate)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+
+	mfaService := service.NewMFAService(userRepository, mainApp.Validate, mainApp.PasswordHasher)
+	mfaController := controller.NewMFAController(mfaService)
+
+	refreshTokenRepository := repository.NewRefreshTokenRepository(mainApp.DB)
+	revokedTokenRepository := repository.NewRevokedTokenRepository(mainApp.DB)
+	mfaChallengeRepository := repository.NewMFAChallengeRepository(mainApp.DB)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(mainApp.DB)
+	authService := service.NewAuthService(userRepository, refreshTokenRepository, revokedTokenRepository, roleRepository, apiKeyRepository, mfaChallengeRepository, loginAttemptRepository, emailVerificationService, mainApp.Validate, mainApp.JWTProvider, mainApp.PasswordHasher, mainApp.RefreshTokenExpiration)
 	authController := controller.NewAuthController(authService, userService)
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 
 	stackRepository := repository.NewStackRepository(mainApp.DB, mainApp.SnowflakeNode)
-	stackService := service.NewStackService(stackRepository, userRepository, mainApp.Validate)
+	stackService := service.NewStackService(stackRepository, userRepository, mainApp.Validate, mainApp.ItemMaxSize)
 	stackController := controller.NewStackController(stackService)
 
+	queueRepository := repository.NewQueueRepository(mainApp.DB, mainApp.SnowflakeNode)
+	queueService := service.NewQueueService(queueRepository, userRepository, mainApp.Validate, mainApp.ItemMaxSize)
+	queueController := controller.NewQueueController(queueService)
+
+	treeRepository := repository.NewTreeRepository(mainApp.DB, mainApp.SnowflakeNode)
+	treeService := service.NewTreeService(treeRepository, userRepository, mainApp.Validate)
+	treeController := controller.NewTreeController(treeService)
+
+	orderedMapRepository := repository.NewOrderedMapRepository(mainApp.DB, mainApp.SnowflakeNode)
+	orderedMapService := service.NewOrderedMapService(orderedMapRepository, userRepository, mainApp.Validate)
+	orderedMapController := controller.NewOrderedMapController(orderedMapService)
+
 	docsController := controller.NewDocsController()
 
-	mainApp.SetupRouter(userController, authController, stackController, docsController, authMiddleware)
+	mainApp.SetupRouter(userController, authController, roleController, apiKeyController, mfaController, stackController, queueController, treeController, orderedMapController, docsController, authMiddleware)
 
 	mainApp.Run()
 }
@@ -49,7 +49,7 @@ func (middleware *AuthMiddleware) Use(prefixs ...string) func(ctx *fiber.Ctx) er
 
 		response, err := middleware.authService.Validate(authorization)
 		if err != nil {
-			statusCode := http.StatusBadRequest
+			statusCode := http.StatusInternalServerError
 			if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrUnauthorized) {
 				statusCode = http.StatusUnauthorized
 			}
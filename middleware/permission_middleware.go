@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"godas/model/domain"
+	"godas/model/web"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Only let requests through when the authenticated user holds every permission.
+// It runs after the auth middleware, which sets the user of the request.
+func RequirePermission(permissions ...domain.Permission) func(ctx *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+		if !isAuthResponse {
+			return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+		}
+
+		// The caller is known but not allowed
+		for _, permission := range permissions {
+			if !authResponse.Can(permission) {
+				return ctx.Status(http.StatusForbidden).JSON(web.NewFailPayload(http.StatusForbidden))
+			}
+		}
+
+		return ctx.Next()
+	}
+}
+
+// Only let requests through that were signed in with a password, API keys cannot manage the account
+// they belong to. It runs after the auth middleware, which sets the user of the request.
+func RefuseAPIKey() func(ctx *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		authResponse, isAuthResponse := ctx.UserContext().Value("response").(web.AuthResponse)
+		if !isAuthResponse || authResponse.APIKeyID != "" {
+			return ctx.Status(http.StatusUnauthorized).JSON(web.NewFailPayload(http.StatusUnauthorized))
+		}
+
+		return ctx.Next()
+	}
+}
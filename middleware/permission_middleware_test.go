@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"context"
+	"godas/middleware"
+	"godas/model/domain"
+	"godas/model/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []domain.Permission
+		status      int
+	}{
+		{"granted", []domain.Permission{domain.PermissionUsersDelete, domain.PermissionUsersRevoke}, http.StatusOK},
+		{"missing one", []domain.Permission{domain.PermissionUsersDelete}, http.StatusForbidden},
+		{"none", nil, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(ctx *fiber.Ctx) error {
+				ctx.SetUserContext(context.WithValue(context.Background(), "response", web.AuthResponse{
+					ID:          "user",
+					Permissions: test.permissions,
+				}))
+				return ctx.Next()
+			})
+			app.Get("/", middleware.RequirePermission(domain.PermissionUsersDelete, domain.PermissionUsersRevoke), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(http.StatusOK)
+			})
+
+			res, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, res.StatusCode)
+			}
+		})
+	}
+
+	app := fiber.New()
+	app.Get("/", middleware.RequirePermission(domain.PermissionUsersDelete), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(http.StatusOK)
+	})
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthenticated requests to be rejected, got %d", res.StatusCode)
+	}
+}
+
+func TestRefuseAPIKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		authResponse web.AuthResponse
+		status       int
+	}{
+		{"access token", web.AuthResponse{ID: "user", TokenID: "token"}, http.StatusOK},
+		{"api key", web.AuthResponse{ID: "user", APIKeyID: "key"}, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(ctx *fiber.Ctx) error {
+				ctx.SetUserContext(context.WithValue(context.Background(), "response", test.authResponse))
+				return ctx.Next()
+			})
+			app.Get("/", middleware.RefuseAPIKey(), func(ctx *fiber.Ctx) error {
+				return ctx.SendStatus(http.StatusOK)
+			})
+
+			res, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, res.StatusCode)
+			}
+		})
+	}
+}
@@ -1,20 +1,35 @@
 package domain
 
+// Purposes of an email verification, every address has at most one pending verification per purpose
+const (
+	EmailVerificationPurposeSignup        = "signup"
+	EmailVerificationPurposePasswordReset = "password-reset"
+	EmailVerificationPurposeEmailChange   = "email-change"
+)
+
 type EmailVerificationSend struct {
-	FromName     string
-	FromEmail    string
-	FromPassword string
-	ToEmail      string
+	FromName  string
+	FromEmail string
+	ToEmail   string
+	Purpose   string
+	UserID    string
 
 	Title string
-
-	Host string
-	Port string
 }
 
+// Verifications stored before purposes existed have none, they are signup verifications.
+// UserID is the user that asked for an email change, the verification is sent to the new address.
+// Attempts counts the codes checked against the current code.
+// Only the hashes of the code and of the token of the link are stored, Code holds the plaintext
+// code of verifications stored before codes were hashed.
 type EmailVerification struct {
 	Email      string `json:"email" bson:"email"`
-	Code       string `json:"code" bson:"code"`
+	Purpose    string `json:"purpose" bson:"purpose,omitempty"`
+	UserID     string `json:"userId" bson:"userId,omitempty"`
+	Code       string `json:"-" bson:"code,omitempty"`
+	CodeHash   string `json:"-" bson:"codeHash,omitempty"`
+	LinkHash   string `json:"-" bson:"linkHash,omitempty"`
 	Expiration int64  `json:"expiration" bson:"expiration"`
 	Cooldown   int64  `json:"cooldown" bson:"cooldown"`
+	Attempts   int    `json:"attempts" bson:"attempts"`
 }
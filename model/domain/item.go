@@ -1,6 +1,10 @@
 package domain
 
+// Value holds an arbitrary JSON value converted to BSON.
+// HasValue is set when a value was sent, so that a null value is told apart from no value.
 type Item struct {
-	Index uint64 `json:"index"`
-	Name  string `json:"name"`
+	Index    uint64 `json:"index" bson:"index"`
+	Name     string `json:"name" bson:"name"`
+	Value    any    `json:"value,omitempty" bson:"value,omitempty"`
+	HasValue bool   `json:"-" bson:"hasValue,omitempty"`
 }
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Failed sign ins of an account or an address, ID is the kind of key followed by the key itself.
+// Sign ins are refused until LockedUntil, the counter is forgotten at ExpiresAt.
+type LoginAttempt struct {
+	ID          string    `json:"id" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LockedUntil time.Time `json:"lockedUntil" bson:"lockedUntil,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt" bson:"expiresAt"`
+}
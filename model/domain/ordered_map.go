@@ -0,0 +1,48 @@
+package domain
+
+type OrderedMapKind string
+
+const (
+	OrderedMapAVL      OrderedMapKind = "avl"
+	OrderedMapRedBlack OrderedMapKind = "red-black"
+)
+
+type NodeColor string
+
+const (
+	NodeColorRed   NodeColor = "red"
+	NodeColorBlack NodeColor = "black"
+)
+
+type RotationDirection string
+
+const (
+	RotationLeft  RotationDirection = "left"
+	RotationRight RotationDirection = "right"
+)
+
+// Rotation records a single rotation around the node with the given key
+type Rotation struct {
+	Direction RotationDirection `json:"direction"`
+	Key       int64             `json:"key"`
+}
+
+// Nodes are stored flat in pre-order like TreeNode.
+// Color is only used by red-black maps.
+type OrderedMapNode struct {
+	Key    int64     `json:"key" bson:"key"`
+	Value  string    `json:"value" bson:"value"`
+	Left   int       `json:"left" bson:"left"`
+	Right  int       `json:"right" bson:"right"`
+	Height int       `json:"height" bson:"height"`
+	Color  NodeColor `json:"color,omitempty" bson:"color,omitempty"`
+}
+
+// Version is increased on every update like the version of a Tree
+type OrderedMap struct {
+	ID      string           `json:"id" bson:"_id"`
+	Kind    OrderedMapKind   `json:"kind" bson:"kind"`
+	Nodes   []OrderedMapNode `json:"nodes" bson:"nodes"`
+	Owner   string           `json:"owner" bson:"owner"`
+	Version uint64           `json:"-" bson:"version"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Next is the index of the next enqueued item, indexes keep increasing as items are dequeued
+type Queue struct {
+	ID    string `json:"id" bson:"_id"`
+	Items []Item `json:"items" bson:"items"`
+	Owner string `json:"owner" bson:"owner"`
+	Next  uint64 `json:"-" bson:"next"`
+}
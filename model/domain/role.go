@@ -0,0 +1,38 @@
+package domain
+
+type Permission string
+
+// Permissions granted through roles, the any permissions reach data owned by other users
+const (
+	PermissionUsersCreate    Permission = "users:create"
+	PermissionUsersUpdate    Permission = "users:update"
+	PermissionUsersDelete    Permission = "users:delete"
+	PermissionUsersRevoke    Permission = "users:revoke"
+	PermissionRolesManage    Permission = "roles:manage"
+	PermissionRolesAssign    Permission = "roles:assign"
+	PermissionStacksReadAny  Permission = "stacks:read:any"
+	PermissionStacksWriteAny Permission = "stacks:write:any"
+)
+
+var Permissions = []Permission{
+	PermissionUsersCreate,
+	PermissionUsersUpdate,
+	PermissionUsersDelete,
+	PermissionUsersRevoke,
+	PermissionRolesManage,
+	PermissionRolesAssign,
+	PermissionStacksReadAny,
+	PermissionStacksWriteAny,
+}
+
+// Built-in roles, admin always holds every permission
+const (
+	RoleClient = "client"
+	RoleAdmin  = "admin"
+)
+
+type Role struct {
+	ID          string       `json:"id" bson:"_id"`
+	Permissions []Permission `json:"permissions" bson:"permissions"`
+	BuiltIn     bool         `json:"builtIn" bson:"builtIn"`
+}
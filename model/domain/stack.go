@@ -1,7 +1,35 @@
 package domain
 
+import "time"
+
+type OverflowPolicy string
+
+// What happens when pushing to a stack holding MaxSize items
+const (
+	OverflowPolicyReject     OverflowPolicy = "reject"
+	OverflowPolicyDropOldest OverflowPolicy = "drop-oldest"
+	OverflowPolicyBlock      OverflowPolicy = "block"
+)
+
+// Schema is an optional JSON Schema every pushed item value is validated against.
+// A zero MaxSize means the stack is unbounded, BlockTimeout is in milliseconds.
 type Stack struct {
-	ID    string `json:"id" bson:"_id"`
-	Items []Item `json:"items" bson:"items"`
-	Owner string `json:"owner" bson:"owner"`
+	ID             string         `json:"id" bson:"_id"`
+	Name           string         `json:"name" bson:"name"`
+	Description    string         `json:"description" bson:"description"`
+	Tags           []string       `json:"tags" bson:"tags"`
+	Items          []Item         `json:"items" bson:"items"`
+	Owner          string         `json:"owner" bson:"owner"`
+	Schema         string         `json:"schema,omitempty" bson:"schema,omitempty"`
+	MaxSize        uint64         `json:"maxSize,omitempty" bson:"maxSize,omitempty"`
+	OverflowPolicy OverflowPolicy `json:"overflowPolicy,omitempty" bson:"overflowPolicy,omitempty"`
+	BlockTimeout   uint64         `json:"blockTimeout,omitempty" bson:"blockTimeout,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Stack read without its items
+type StackSummary struct {
+	Stack `bson:",inline"`
+	Size  uint64 `json:"size" bson:"size"`
 }
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// ID is the jti claim of a revoked access token, kept until the token expires
+type RevokedToken struct {
+	ID        string    `json:"id" bson:"_id"`
+	UserID    string    `json:"userId" bson:"userId"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+}
+
+// ID is the SHA-256 hash of the token, the token itself is never stored.
+// Every token rotated from the same signin shares its Family.
+type RefreshToken struct {
+	ID        string    `json:"id" bson:"_id"`
+	Family    string    `json:"family" bson:"family"`
+	UserID    string    `json:"userId" bson:"userId"`
+	Used      bool      `json:"used" bson:"used"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// Keys are DER encoded, PKCS #8 for the private key and PKIX for the public key.
+// A key signs tokens until RetiresAt and is kept to verify them until ExpiresAt.
+type SigningKey struct {
+	ID         string    `json:"id" bson:"_id"`
+	Algorithm  string    `json:"algorithm" bson:"algorithm"`
+	PrivateKey []byte    `json:"-" bson:"privateKey"`
+	PublicKey  []byte    `json:"publicKey" bson:"publicKey"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+	RetiresAt  time.Time `json:"retiresAt" bson:"retiresAt"`
+	ExpiresAt  time.Time `json:"expiresAt" bson:"expiresAt"`
+}
+
+// Only the hash of an API key is stored, Prefix is the start of the key so that users can recognize it.
+// Scopes limit the permissions of the key to the ones the user also holds through its roles.
+type APIKey struct {
+	ID         string       `json:"id" bson:"_id"`
+	UserID     string       `json:"userId" bson:"userId"`
+	Name       string       `json:"name" bson:"name"`
+	Hash       string       `json:"-" bson:"hash"`
+	Prefix     string       `json:"prefix" bson:"prefix"`
+	Scopes     []Permission `json:"scopes" bson:"scopes"`
+	ExpiresAt  time.Time    `json:"expiresAt" bson:"expiresAt"`
+	LastUsedAt time.Time    `json:"lastUsedAt" bson:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt" bson:"createdAt"`
+}
+
+// A sign in waiting for its second factor, ID is the hash of the token given to the client
+type MFAChallenge struct {
+	ID        string    `json:"id" bson:"_id"`
+	UserID    string    `json:"userId" bson:"userId"`
+	Attempts  int       `json:"attempts" bson:"attempts"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+}
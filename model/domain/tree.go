@@ -0,0 +1,26 @@
+package domain
+
+type TraversalOrder string
+
+const (
+	TraversalInOrder    TraversalOrder = "in-order"
+	TraversalPreOrder   TraversalOrder = "pre-order"
+	TraversalPostOrder  TraversalOrder = "post-order"
+	TraversalLevelOrder TraversalOrder = "level-order"
+)
+
+// Nodes are stored flat in pre-order, so the root is always the first node.
+// Left and Right point to the index of the child node, or -1 if there is none.
+type TreeNode struct {
+	Key   int64 `json:"key" bson:"key"`
+	Left  int   `json:"left" bson:"left"`
+	Right int   `json:"right" bson:"right"`
+}
+
+// Version is increased on every update so that concurrent updates cannot overwrite each other
+type Tree struct {
+	ID      string     `json:"id" bson:"_id"`
+	Nodes   []TreeNode `json:"nodes" bson:"nodes"`
+	Owner   string     `json:"owner" bson:"owner"`
+	Version uint64     `json:"-" bson:"version"`
+}
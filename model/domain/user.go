@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type UserRole int
 
 const (
@@ -7,11 +9,32 @@ const (
 	UserRoleAdmin
 )
 
+// Tokens issued up to TokensRevokedAt are no longer accepted.
+// Role is only read for users that were stored before roles could be assigned and have no Roles.
+// TOTPSecret is set once two-factor authentication is enrolled, it is only required once TOTPEnabled
+// is set. TOTPLastStep is the period of the last accepted code and RecoveryCodes are hashed.
 type User struct {
-	ID       string   `json:"id" bson:"_id"`
-	Name     string   `json:"name" bson:"name"`
-	Role     UserRole `json:"role" bson:"role"`
-	Email    string   `json:"email" bson:"email"`
-	Password string   `json:"password" bson:"password"`
-	Verified bool     `json:"verified" bson:"verified"`
+	ID              string    `json:"id" bson:"_id"`
+	Name            string    `json:"name" bson:"name"`
+	Role            UserRole  `json:"role" bson:"role"`
+	Roles           []string  `json:"roles" bson:"roles,omitempty"`
+	Email           string    `json:"email" bson:"email"`
+	Password        string    `json:"password" bson:"password"`
+	Verified        bool      `json:"verified" bson:"verified"`
+	TokensRevokedAt time.Time `json:"tokensRevokedAt" bson:"tokensRevokedAt,omitempty"`
+	TOTPSecret      string    `json:"-" bson:"totpSecret,omitempty"`
+	TOTPEnabled     bool      `json:"totpEnabled" bson:"totpEnabled,omitempty"`
+	TOTPLastStep    int64     `json:"-" bson:"totpLastStep,omitempty"`
+	RecoveryCodes   []string  `json:"-" bson:"recoveryCodes,omitempty"`
+}
+
+func (user User) RoleNames() []string {
+	if len(user.Roles) > 0 {
+		return user.Roles
+	}
+	if user.Role == UserRoleAdmin {
+		return []string{RoleAdmin}
+	}
+
+	return []string{RoleClient}
 }
@@ -0,0 +1,30 @@
+package web
+
+import (
+	"godas/model/domain"
+	"time"
+)
+
+// ExpiresInDays defaults to 90 days when it is not set
+type APIKeyCreateRequest struct {
+	Name          string              `json:"name" validate:"required,max=128"`
+	Scopes        []domain.Permission `json:"scopes" validate:"max=32,dive,required"`
+	ExpiresInDays int                 `json:"expiresInDays" validate:"omitempty,min=1,max=365"`
+}
+
+// LastUsedAt is null until the key is used, it is only updated about once a minute
+type APIKeyResponse struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Prefix     string              `json:"prefix"`
+	Scopes     []domain.Permission `json:"scopes"`
+	ExpiresAt  time.Time           `json:"expiresAt"`
+	LastUsedAt *time.Time          `json:"lastUsedAt"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+// The key is only returned when it is created
+type APIKeyCreateResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
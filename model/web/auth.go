@@ -2,22 +2,114 @@ package web
 
 import (
 	"godas/model/domain"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// Roles are informative, requests are authorized with the roles stored with the user
+// IssuedAtMilli is the issue time in milliseconds, iat only holds whole seconds
 type JwtClaims struct {
-	UserID string          `json:"id"`
-	Role   domain.UserRole `json:"role"`
+	UserID        string   `json:"id"`
+	Roles         []string `json:"roles,omitempty"`
+	IssuedAtMilli int64    `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IP is the address the request came from, it is set by the server and never read from the body
 type AuthRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
+	IP       string `json:"-"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// ExpiresIn is the lifetime of the access token in seconds.
+// When the user has two-factor authentication enabled only MFARequired and MFAToken are set,
+// the tokens are issued once the MFA token is sent back with a code.
+type TokenResponse struct {
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	TokenType    string `json:"tokenType,omitempty"`
+	ExpiresIn    int64  `json:"expiresIn,omitempty"`
+	MFARequired  bool   `json:"mfaRequired,omitempty"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+}
+
+// The code is either a TOTP code or a recovery code
+type MFASigninRequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// The secret and the recovery codes are only returned once, URI is meant to be shown as a QR code
+type MFAEnrollResponse struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MFAConfirmRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// Both the password and a code are asked before two-factor authentication is turned off
+type MFADisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PasswordResetRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Code     string `json:"code" validate:"required,min=6,max=32"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// The refresh token is optional, when given its whole session is ended too
+type SignoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// TokenID and TokenExpiresAt describe the access token the request was authenticated with,
+// APIKeyID is set instead of TokenID when the request was authenticated with an API key
 type AuthResponse struct {
-	ID   string          `json:"id"`
-	Role domain.UserRole `json:"role"`
+	ID             string              `json:"id"`
+	Roles          []string            `json:"roles"`
+	Permissions    []domain.Permission `json:"permissions"`
+	TokenID        string              `json:"-"`
+	TokenExpiresAt time.Time           `json:"-"`
+	APIKeyID       string              `json:"-"`
+}
+
+func (response AuthResponse) Can(permission domain.Permission) bool {
+	for _, granted := range response.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Public key in the JSON Web Key format of RFC 7517, N and E are set for RSA keys and Crv and X for Ed25519 keys
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
 }
@@ -1,8 +1,10 @@
 package web
 
+// Either the emailed code or the token of the emailed link is given
 type EmailVerificationCreateRequest struct {
 	Email string `json:"email" validate:"required,email"`
-	Code  string `json:"code" validate:"required,min=6,max=6"`
+	Code  string `json:"code" validate:"required_without=Token,omitempty,min=6,max=32"`
+	Token string `json:"token" validate:"required_without=Code,omitempty,max=128"`
 }
 
 type EmailVerificationRecreateRequest struct {
@@ -1,10 +1,14 @@
 package web
 
+import "encoding/json"
+
 type ItemRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=128"`
+	Name  string          `json:"name" validate:"required_without=Value,max=128"`
+	Value json.RawMessage `json:"value,omitempty"`
 }
 
 type ItemResponse struct {
-	Index uint64 `json:"index"`
-	Name  string `json:"name"`
+	Index uint64          `json:"index"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value,omitempty"`
 }
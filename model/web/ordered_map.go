@@ -0,0 +1,42 @@
+package web
+
+import "godas/model/domain"
+
+type OrderedMapCreateRequest struct {
+	Kind domain.OrderedMapKind `json:"kind" validate:"required,oneof=avl red-black"`
+}
+
+type OrderedMapEntryRequest struct {
+	Value string `json:"value" validate:"max=128"`
+}
+
+type OrderedMapNodeResponse struct {
+	Key           int64            `json:"key"`
+	Value         string           `json:"value"`
+	Left          int              `json:"left"`
+	Right         int              `json:"right"`
+	Height        int              `json:"height"`
+	BalanceFactor *int             `json:"balanceFactor,omitempty"`
+	Color         domain.NodeColor `json:"color,omitempty"`
+}
+
+type OrderedMapResponse struct {
+	ID     string                   `json:"id"`
+	Owner  string                   `json:"owner"`
+	Kind   domain.OrderedMapKind    `json:"kind"`
+	Size   int                      `json:"size"`
+	Height int                      `json:"height"`
+	Nodes  []OrderedMapNodeResponse `json:"nodes"`
+}
+
+type OrderedMapEntryResponse struct {
+	Key   int64  `json:"key"`
+	Value string `json:"value"`
+}
+
+type OrderedMapMutationResponse struct {
+	Key       int64             `json:"key"`
+	Value     string            `json:"value"`
+	Inserted  bool              `json:"inserted"`
+	Rotations []domain.Rotation `json:"rotations"`
+}
@@ -2,10 +2,17 @@ package web
 
 import "net/http"
 
+// Machine-readable error codes, sent when the status code alone is ambiguous
+const (
+	ErrorCodeEmpty = "EMPTY"
+	ErrorCodeFull  = "FULL"
+)
+
 type Payload struct {
 	Code    int    `json:"code"`
 	Status  string `json:"status"`
 	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
 	Data    any    `json:"data"`
 }
 
@@ -17,3 +24,10 @@ func NewFailPayload(statusCode int) Payload {
 		Data:    nil,
 	}
 }
+
+func NewErrorPayload(statusCode int, errorCode string) Payload {
+	payload := NewFailPayload(statusCode)
+	payload.Error = errorCode
+
+	return payload
+}
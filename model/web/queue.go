@@ -0,0 +1,10 @@
+package web
+
+type QueueCreateRequest struct {
+}
+
+type QueueResponse struct {
+	ID    string         `json:"id"`
+	Owner string         `json:"owner"`
+	Items []ItemResponse `json:"items"`
+}
@@ -0,0 +1,13 @@
+package web
+
+import "godas/model/domain"
+
+type RoleRequest struct {
+	Permissions []domain.Permission `json:"permissions" validate:"required,max=64,dive,required"`
+}
+
+type RoleResponse struct {
+	ID          string              `json:"id"`
+	Permissions []domain.Permission `json:"permissions"`
+	BuiltIn     bool                `json:"builtIn"`
+}
@@ -1,12 +1,82 @@
 package web
 
-import "godas/model/domain"
+import (
+	"encoding/json"
+	"godas/model/domain"
+	"time"
+)
 
+// BlockTimeout is in milliseconds
 type StackCreateRequest struct {
+	Name           string                `json:"name" validate:"max=128"`
+	Description    string                `json:"description" validate:"max=1024"`
+	Tags           []string              `json:"tags" validate:"max=32,dive,required,max=64"`
+	Schema         json.RawMessage       `json:"schema,omitempty"`
+	MaxSize        uint64                `json:"maxSize"`
+	OverflowPolicy domain.OverflowPolicy `json:"overflowPolicy" validate:"omitempty,oneof=reject drop-oldest block"`
+	BlockTimeout   uint64                `json:"blockTimeout" validate:"max=30000"`
+}
+
+// Name matches stacks whose name starts with it, Cursor is the next cursor of the previous page
+type StackFilterRequest struct {
+	Tag    string `query:"tag"`
+	Name   string `query:"name"`
+	Limit  int    `query:"limit" validate:"min=0,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+// From is either top or bottom, the top by default
+type StackItemsRequest struct {
+	From   string `query:"from" validate:"omitempty,oneof=top bottom"`
+	Offset int    `query:"offset" validate:"min=0"`
+	Limit  int    `query:"limit" validate:"min=0,max=1000"`
+}
+
+type StackSchemaRequest struct {
+	Schema json.RawMessage `json:"schema"`
 }
 
 type StackResponse struct {
-	ID    string        `json:"id"`
-	Owner string        `json:"owner"`
-	Items []domain.Item `json:"items"`
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Tags           []string              `json:"tags"`
+	Owner          string                `json:"owner"`
+	Schema         json.RawMessage       `json:"schema,omitempty"`
+	MaxSize        uint64                `json:"maxSize,omitempty"`
+	OverflowPolicy domain.OverflowPolicy `json:"overflowPolicy,omitempty"`
+	BlockTimeout   uint64                `json:"blockTimeout,omitempty"`
+	Items          []ItemResponse        `json:"items"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
+
+type StackSummaryResponse struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Tags           []string              `json:"tags"`
+	Owner          string                `json:"owner"`
+	Schema         json.RawMessage       `json:"schema,omitempty"`
+	MaxSize        uint64                `json:"maxSize,omitempty"`
+	OverflowPolicy domain.OverflowPolicy `json:"overflowPolicy,omitempty"`
+	BlockTimeout   uint64                `json:"blockTimeout,omitempty"`
+	Size           uint64                `json:"size"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
+
+// NextCursor is empty on the last page
+type StackPageResponse struct {
+	Stacks     []StackSummaryResponse `json:"stacks"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+type StackItemsResponse struct {
+	Size  uint64         `json:"size"`
+	Items []ItemResponse `json:"items"`
+}
+
+type StackSizeResponse struct {
+	Size uint64 `json:"size"`
 }
@@ -0,0 +1,27 @@
+package web
+
+import "godas/model/domain"
+
+type TreeCreateRequest struct {
+}
+
+type TreeNodeRequest struct {
+	Key *int64 `json:"key" validate:"required"`
+}
+
+type TreeResponse struct {
+	ID     string            `json:"id"`
+	Owner  string            `json:"owner"`
+	Size   int               `json:"size"`
+	Height int               `json:"height"`
+	Nodes  []domain.TreeNode `json:"nodes"`
+}
+
+type TreeNodeResponse struct {
+	Key int64 `json:"key"`
+}
+
+type TraversalResponse struct {
+	Order domain.TraversalOrder `json:"order"`
+	Keys  []int64               `json:"keys"`
+}
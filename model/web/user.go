@@ -10,6 +10,21 @@ type UserUpdateRequest struct {
 	Name string `json:"name" validate:"min=1,max=128"`
 }
 
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+}
+
+// The current password is asked again before a code is sent to the new address
+type EmailChangeRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type UserRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,min=1,max=16,dive,required,max=64"`
+}
+
 type UserResponse struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
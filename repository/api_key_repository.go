@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository interface {
+	Insert(context.Context, domain.APIKey) (domain.APIKey, error)
+	FindByHash(context.Context, string) (domain.APIKey, error)
+	FindByUser(context.Context, string) ([]domain.APIKey, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+	DeleteFromOwner(ctx context.Context, id string, owner string) error
+}
+
+type APIKeyRepositoryImpl struct {
+	collection    *mongo.Collection
+	snowflakeNode *snowflake.Node
+}
+
+func NewAPIKeyRepository(db *mongo.Database, snowflakeNode *snowflake.Node) APIKeyRepository {
+	repository := new(APIKeyRepositoryImpl)
+	repository.collection = db.Collection("apiKeys")
+	repository.snowflakeNode = snowflakeNode
+
+	// Create Index, expired keys are removed by MongoDB
+	_, err := repository.collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.M{"hash": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.M{"userId": 1},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *APIKeyRepositoryImpl) Insert(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, error) {
+	apiKey.ID = repository.snowflakeNode.Generate().String()
+
+	_, err := repository.collection.InsertOne(ctx, apiKey)
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return apiKey, ErrDuplicateData
+		}
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+func (repository *APIKeyRepositoryImpl) FindByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	apiKey := domain.APIKey{}
+
+	res := repository.collection.FindOne(ctx, bson.M{"hash": hash})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return apiKey, ErrNoData
+		}
+		return apiKey, err
+	}
+
+	err := res.Decode(&apiKey)
+	return apiKey, err
+}
+
+// Newest keys first
+func (repository *APIKeyRepositoryImpl) FindByUser(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	cur, err := repository.collection.Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []domain.APIKey{}
+	err = cur.All(ctx, &apiKeys)
+
+	return apiKeys, err
+}
+
+func (repository *APIKeyRepositoryImpl) Touch(ctx context.Context, id string, at time.Time) error {
+	_, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+func (repository *APIKeyRepositoryImpl) DeleteFromOwner(ctx context.Context, id string, owner string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id, "userId": owner})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount < 1 {
+		return ErrNoData
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/repository"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeyRepository := repository.NewAPIKeyRepository(newTestDatabase(t), snowflakeNode)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	apiKey, err := apiKeyRepository.Insert(ctx, domain.APIKey{
+		UserID:    "user",
+		Name:      "ci",
+		Hash:      "hash",
+		Prefix:    "godas_abcdef",
+		Scopes:    []domain.Permission{domain.PermissionStacksReadAny},
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apiKeyRepository.Insert(ctx, domain.APIKey{UserID: "other", Hash: "hash", ExpiresAt: now.Add(time.Hour)}); !errors.Is(err, repository.ErrDuplicateData) {
+		t.Fatalf("expected a duplicate hash to be refused, got %v", err)
+	}
+
+	found, err := apiKeyRepository.FindByHash(ctx, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != apiKey.ID || found.Name != "ci" || len(found.Scopes) != 1 {
+		t.Fatalf("expected the inserted key, got %+v", found)
+	}
+
+	if err := apiKeyRepository.Touch(ctx, apiKey.ID, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	apiKeys, err := apiKeyRepository.FindByUser(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apiKeys) != 1 || !apiKeys[0].LastUsedAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the last use to be recorded, got %+v", apiKeys)
+	}
+
+	if err := apiKeyRepository.DeleteFromOwner(ctx, apiKey.ID, "other"); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected another user not to delete the key, got %v", err)
+	}
+	if err := apiKeyRepository.DeleteFromOwner(ctx, apiKey.ID, "user"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apiKeyRepository.FindByHash(ctx, "hash"); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected the key to be deleted, got %v", err)
+	}
+}
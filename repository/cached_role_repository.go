@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"godas/model/domain"
+	"sync"
+	"time"
+)
+
+// Cache every role in front of another repository, roles are few and read on every authenticated request.
+// Writes through this repository clear the cache at once, writes made by other instances
+// of the application are seen once the cache expires.
+type CachedRoleRepository struct {
+	RoleRepository
+	ttl       time.Duration
+	mutex     sync.Mutex
+	roles     []domain.Role
+	expiresAt time.Time
+}
+
+func NewCachedRoleRepository(roleRepository RoleRepository, ttl time.Duration) RoleRepository {
+	repository := new(CachedRoleRepository)
+	repository.RoleRepository = roleRepository
+	repository.ttl = ttl
+
+	return repository
+}
+
+func (repository *CachedRoleRepository) FindById(ctx context.Context, id string) (domain.Role, error) {
+	roles, err := repository.FindAll(ctx)
+	if err != nil {
+		return domain.Role{}, err
+	}
+
+	for _, role := range roles {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+
+	return domain.Role{}, ErrNoData
+}
+
+// The returned roles are shared with the cache and must not be modified
+func (repository *CachedRoleRepository) FindAll(ctx context.Context) ([]domain.Role, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if repository.roles != nil && time.Now().Before(repository.expiresAt) {
+		return repository.roles, nil
+	}
+
+	roles, err := repository.RoleRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repository.roles = roles
+	repository.expiresAt = time.Now().Add(repository.ttl)
+
+	return roles, nil
+}
+
+func (repository *CachedRoleRepository) Put(ctx context.Context, role domain.Role) (domain.Role, error) {
+	defer repository.clear()
+	return repository.RoleRepository.Put(ctx, role)
+}
+
+func (repository *CachedRoleRepository) Delete(ctx context.Context, id string) error {
+	defer repository.clear()
+	return repository.RoleRepository.Delete(ctx, id)
+}
+
+func (repository *CachedRoleRepository) clear() {
+	repository.mutex.Lock()
+	repository.roles = nil
+	repository.mutex.Unlock()
+}
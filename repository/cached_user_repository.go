@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"godas/model/domain"
+	"sync"
+	"time"
+)
+
+// Users are read on every authenticated request, they are cached shortly to save a query per request
+const DefaultUserCacheTTL = time.Second * 10
+
+// Above this many entries the expired ones are dropped before a new one is added
+const userCacheMaxSize = 10000
+
+type cachedUser struct {
+	user      domain.User
+	expiresAt time.Time
+}
+
+// Cache users found by id in front of another repository.
+// Writes through this repository evict the user at once, writes made by other instances
+// of the application are seen once the entry expires.
+type CachedUserRepository struct {
+	UserRepository
+	ttl   time.Duration
+	mutex sync.Mutex
+	users map[string]cachedUser
+}
+
+func NewCachedUserRepository(userRepository UserRepository, ttl time.Duration) UserRepository {
+	repository := new(CachedUserRepository)
+	repository.UserRepository = userRepository
+	repository.ttl = ttl
+	repository.users = map[string]cachedUser{}
+
+	return repository
+}
+
+func (repository *CachedUserRepository) FindById(ctx context.Context, id string) (domain.User, error) {
+	now := time.Now()
+
+	repository.mutex.Lock()
+	cached, isCached := repository.users[id]
+	repository.mutex.Unlock()
+	if isCached && now.Before(cached.expiresAt) {
+		return cached.user, nil
+	}
+
+	user, err := repository.UserRepository.FindById(ctx, id)
+	if err != nil {
+		return user, err
+	}
+
+	repository.mutex.Lock()
+	if len(repository.users) >= userCacheMaxSize {
+		for id, cached := range repository.users {
+			if !now.Before(cached.expiresAt) {
+				delete(repository.users, id)
+			}
+		}
+	}
+	if len(repository.users) < userCacheMaxSize {
+		repository.users[id] = cachedUser{user: user, expiresAt: now.Add(repository.ttl)}
+	}
+	repository.mutex.Unlock()
+
+	return user, nil
+}
+
+func (repository *CachedUserRepository) Update(ctx context.Context, user domain.User) (domain.User, error) {
+	defer repository.evict(user.ID)
+	return repository.UserRepository.Update(ctx, user)
+}
+
+func (repository *CachedUserRepository) SetName(ctx context.Context, id string, name string) (domain.User, error) {
+	defer repository.evict(id)
+	return repository.UserRepository.SetName(ctx, id, name)
+}
+
+func (repository *CachedUserRepository) SetPassword(ctx context.Context, id string, password string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.SetPassword(ctx, id, password)
+}
+
+func (repository *CachedUserRepository) SetVerified(ctx context.Context, id string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.SetVerified(ctx, id)
+}
+
+func (repository *CachedUserRepository) SetEmail(ctx context.Context, id string, email string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.SetEmail(ctx, id, email)
+}
+
+func (repository *CachedUserRepository) SetTOTP(ctx context.Context, id string, secret string, recoveryCodes []string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.SetTOTP(ctx, id, secret, recoveryCodes)
+}
+
+func (repository *CachedUserRepository) EnableTOTP(ctx context.Context, id string, step int64) error {
+	defer repository.evict(id)
+	return repository.UserRepository.EnableTOTP(ctx, id, step)
+}
+
+func (repository *CachedUserRepository) DisableTOTP(ctx context.Context, id string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.DisableTOTP(ctx, id)
+}
+
+func (repository *CachedUserRepository) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	defer repository.evict(id)
+	return repository.UserRepository.UseTOTPStep(ctx, id, step)
+}
+
+func (repository *CachedUserRepository) UseRecoveryCode(ctx context.Context, id string, hash string) error {
+	defer repository.evict(id)
+	return repository.UserRepository.UseRecoveryCode(ctx, id, hash)
+}
+
+func (repository *CachedUserRepository) Delete(ctx context.Context, user domain.User) error {
+	defer repository.evict(user.ID)
+	return repository.UserRepository.Delete(ctx, user)
+}
+
+func (repository *CachedUserRepository) RevokeTokens(ctx context.Context, id string, at time.Time) error {
+	defer repository.evict(id)
+	return repository.UserRepository.RevokeTokens(ctx, id, at)
+}
+
+func (repository *CachedUserRepository) SetRoles(ctx context.Context, id string, roles []string, at time.Time) error {
+	defer repository.evict(id)
+	return repository.UserRepository.SetRoles(ctx, id, roles, at)
+}
+
+func (repository *CachedUserRepository) evict(id string) {
+	repository.mutex.Lock()
+	delete(repository.users, id)
+	repository.mutex.Unlock()
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"godas/model/domain"
+	"godas/repository"
+	"testing"
+	"time"
+)
+
+type countingUserRepository struct {
+	repository.UserRepository
+	users map[string]domain.User
+	finds int
+}
+
+func (repository *countingUserRepository) FindById(ctx context.Context, id string) (domain.User, error) {
+	repository.finds++
+	return repository.users[id], nil
+}
+
+func (repository *countingUserRepository) SetRoles(ctx context.Context, id string, roles []string, at time.Time) error {
+	user := repository.users[id]
+	user.Roles = roles
+	user.TokensRevokedAt = at
+	repository.users[id] = user
+	return nil
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingUserRepository{users: map[string]domain.User{
+		"user": {ID: "user", Roles: []string{domain.RoleAdmin}},
+	}}
+	cached := repository.NewCachedUserRepository(inner, time.Millisecond*50)
+
+	for i := 0; i < 3; i++ {
+		user, err := cached.FindById(ctx, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.RoleNames()[0] != domain.RoleAdmin {
+			t.Fatalf("expected the admin role, got %v", user.Roles)
+		}
+	}
+	if inner.finds != 1 {
+		t.Fatalf("expected a single query, got %d", inner.finds)
+	}
+
+	// Writes evict the user
+	if err := cached.SetRoles(ctx, "user", []string{domain.RoleClient}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	user, err := cached.FindById(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.RoleNames()[0] != domain.RoleClient || inner.finds != 2 {
+		t.Fatalf("expected the new role to be read, got %v after %d queries", user.Roles, inner.finds)
+	}
+
+	// Entries expire
+	time.Sleep(time.Millisecond * 60)
+	if _, err := cached.FindById(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.finds != 3 {
+		t.Fatalf("expected an expired entry to be read again, got %d queries", inner.finds)
+	}
+}
@@ -12,9 +12,11 @@ import (
 
 type EmailVerificationRepository interface {
 	Insert(context.Context, domain.EmailVerification) error
-	FindByEmail(context.Context, string) (domain.EmailVerification, error)
+	FindByEmail(ctx context.Context, email string, purpose string) (domain.EmailVerification, error)
 	Update(context.Context, domain.EmailVerification) (domain.EmailVerification, error)
-	Delete(context.Context, string) error
+	Renew(ctx context.Context, emailVerification domain.EmailVerification, at int64) error
+	Attempt(ctx context.Context, email string, purpose string, userID string) (domain.EmailVerification, error)
+	Delete(ctx context.Context, email string, purpose string) error
 }
 
 type EmailVerificationRepositoryImpl struct {
@@ -23,9 +25,15 @@ type EmailVerificationRepositoryImpl struct {
 
 func NewEmailVerificationRepository(db *mongo.Database) EmailVerificationRepository {
 	collection := db.Collection("emailVerifications")
+
+	// Drop the index that allowed a single verification per address, it fails when it is already gone
+	collection.Indexes().DropOne(context.Background(), "email_1")
+
+	// Create Index
 	if _, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
-		Keys: bson.M{
-			"email": 1,
+		Keys: bson.D{
+			{Key: "email", Value: 1},
+			{Key: "purpose", Value: 1},
 		},
 		Options: options.Index().SetUnique(true),
 	}); err != nil {
@@ -49,10 +57,10 @@ func (repository *EmailVerificationRepositoryImpl) Insert(ctx context.Context, e
 	return nil
 }
 
-func (repository *EmailVerificationRepositoryImpl) FindByEmail(ctx context.Context, email string) (domain.EmailVerification, error) {
+func (repository *EmailVerificationRepositoryImpl) FindByEmail(ctx context.Context, email string, purpose string) (domain.EmailVerification, error) {
 	emailVerification := domain.EmailVerification{}
 
-	res := repository.collection.FindOne(ctx, bson.M{"email": email})
+	res := repository.collection.FindOne(ctx, emailVerificationFilter(email, purpose))
 	if err := res.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return emailVerification, ErrNoData
@@ -68,7 +76,7 @@ func (repository *EmailVerificationRepositoryImpl) FindByEmail(ctx context.Conte
 }
 
 func (repository *EmailVerificationRepositoryImpl) Update(ctx context.Context, emailVerification domain.EmailVerification) (domain.EmailVerification, error) {
-	res, err := repository.collection.UpdateOne(ctx, bson.M{"email": emailVerification.Email}, bson.M{"$set": emailVerification})
+	res, err := repository.collection.UpdateOne(ctx, emailVerificationFilter(emailVerification.Email, emailVerification.Purpose), bson.M{"$set": emailVerification})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return emailVerification, ErrNoData
@@ -82,8 +90,59 @@ func (repository *EmailVerificationRepositoryImpl) Update(ctx context.Context, e
 	return emailVerification, nil
 }
 
-func (repository *EmailVerificationRepositoryImpl) Delete(ctx context.Context, email string) error {
-	res, err := repository.collection.DeleteOne(ctx, bson.M{"email": email})
+// Replace a verification whose cooldown is over at the given time in a single update, so that only one
+// of concurrent renewals succeeds. ErrLockedData is returned while the cooldown lasts.
+func (repository *EmailVerificationRepositoryImpl) Renew(ctx context.Context, emailVerification domain.EmailVerification, at int64) error {
+	filter := emailVerificationFilter(emailVerification.Email, emailVerification.Purpose)
+	filter["cooldown"] = bson.M{"$lte": at}
+
+	res, err := repository.collection.UpdateOne(ctx, filter, bson.M{"$set": emailVerification})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := repository.collection.CountDocuments(ctx, emailVerificationFilter(emailVerification.Email, emailVerification.Purpose))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoData
+	}
+	return ErrLockedData
+}
+
+// Count an attempt at the code of a verification, the returned verification includes it.
+// A user id only matches the verification asked for by that user, an empty one matches any.
+func (repository *EmailVerificationRepositoryImpl) Attempt(ctx context.Context, email string, purpose string, userID string) (domain.EmailVerification, error) {
+	emailVerification := domain.EmailVerification{}
+
+	filter := emailVerificationFilter(email, purpose)
+	if userID != "" {
+		filter["userId"] = userID
+	}
+
+	res := repository.collection.FindOneAndUpdate(ctx, filter, bson.M{
+		"$inc": bson.M{"attempts": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return emailVerification, ErrNoData
+		}
+		return emailVerification, err
+	}
+
+	if err := res.Decode(&emailVerification); err != nil {
+		return emailVerification, err
+	}
+
+	return emailVerification, nil
+}
+
+func (repository *EmailVerificationRepositoryImpl) Delete(ctx context.Context, email string, purpose string) error {
+	res, err := repository.collection.DeleteOne(ctx, emailVerificationFilter(email, purpose))
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return ErrNoData
@@ -96,3 +155,12 @@ func (repository *EmailVerificationRepositoryImpl) Delete(ctx context.Context, e
 
 	return nil
 }
+
+// Signup verifications also match the ones stored without a purpose
+func emailVerificationFilter(email string, purpose string) bson.M {
+	if purpose == domain.EmailVerificationPurposeSignup {
+		return bson.M{"email": email, "purpose": bson.M{"$in": bson.A{purpose, nil}}}
+	}
+
+	return bson.M{"email": email, "purpose": purpose}
+}
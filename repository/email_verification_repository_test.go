@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/repository"
+	"testing"
+)
+
+func TestEmailVerificationRepositoryPurposes(t *testing.T) {
+	db := newTestDatabase(t)
+	emailVerificationRepository := repository.NewEmailVerificationRepository(db)
+	ctx := context.Background()
+
+	// Stored before purposes existed
+	if _, err := db.Collection("emailVerifications").InsertOne(ctx, domain.EmailVerification{Email: "a@example.com", Code: "LEGACY"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := emailVerificationRepository.Insert(ctx, domain.EmailVerification{
+		Email:   "a@example.com",
+		Purpose: domain.EmailVerificationPurposePasswordReset,
+		Code:    "RESET1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := emailVerificationRepository.Insert(ctx, domain.EmailVerification{
+		Email:   "a@example.com",
+		Purpose: domain.EmailVerificationPurposePasswordReset,
+		Code:    "RESET2",
+	}); !errors.Is(err, repository.ErrDuplicateData) {
+		t.Fatalf("expected a single verification per purpose, got %v", err)
+	}
+
+	signup, err := emailVerificationRepository.FindByEmail(ctx, "a@example.com", domain.EmailVerificationPurposeSignup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signup.Code != "LEGACY" {
+		t.Fatalf("expected the verification without a purpose to be a signup one, got %+v", signup)
+	}
+
+	if err := emailVerificationRepository.Delete(ctx, "a@example.com", domain.EmailVerificationPurposePasswordReset); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emailVerificationRepository.FindByEmail(ctx, "a@example.com", domain.EmailVerificationPurposePasswordReset); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected the reset code to be deleted, got %v", err)
+	}
+	if _, err := emailVerificationRepository.FindByEmail(ctx, "a@example.com", domain.EmailVerificationPurposeSignup); err != nil {
+		t.Fatalf("expected the signup code to be kept, got %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		attempted, err := emailVerificationRepository.Attempt(ctx, "a@example.com", domain.EmailVerificationPurposeSignup, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempted.Attempts != i {
+			t.Fatalf("expected %d attempts, got %+v", i, attempted)
+		}
+	}
+	if _, err := emailVerificationRepository.Attempt(ctx, "a@example.com", domain.EmailVerificationPurposePasswordReset, ""); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected no reset code to attempt, got %v", err)
+	}
+}
+
+func TestEmailVerificationRepositoryAttemptOfUser(t *testing.T) {
+	emailVerificationRepository := repository.NewEmailVerificationRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	if err := emailVerificationRepository.Insert(ctx, domain.EmailVerification{
+		Email:   "new@example.com",
+		Purpose: domain.EmailVerificationPurposeEmailChange,
+		UserID:  "1",
+		Code:    "CHANGE",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := emailVerificationRepository.Attempt(ctx, "new@example.com", domain.EmailVerificationPurposeEmailChange, "2"); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected the verification of another user not to be attempted, got %v", err)
+	}
+	attempted, err := emailVerificationRepository.Attempt(ctx, "new@example.com", domain.EmailVerificationPurposeEmailChange, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempted.Attempts != 1 {
+		t.Fatalf("expected only the attempt of the user to be counted, got %+v", attempted)
+	}
+}
+
+func TestEmailVerificationRepositoryRenew(t *testing.T) {
+	emailVerificationRepository := repository.NewEmailVerificationRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	renewal := domain.EmailVerification{Email: "a@example.com", Purpose: domain.EmailVerificationPurposeSignup, CodeHash: "new", Cooldown: 200}
+	if err := emailVerificationRepository.Renew(ctx, renewal, 100); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if err := emailVerificationRepository.Insert(ctx, domain.EmailVerification{Email: "a@example.com", Purpose: domain.EmailVerificationPurposeSignup, CodeHash: "old", Cooldown: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one of concurrent renewals claims the cooldown
+	const renewals = 16
+	results := make(chan error, renewals)
+	for i := 0; i < renewals; i++ {
+		go func() {
+			results <- emailVerificationRepository.Renew(ctx, renewal, 100)
+		}()
+	}
+	renewed := 0
+	for i := 0; i < renewals; i++ {
+		err := <-results
+		if err == nil {
+			renewed++
+		} else if !errors.Is(err, repository.ErrLockedData) {
+			t.Fatal(err)
+		}
+	}
+	if renewed != 1 {
+		t.Fatalf("expected a single renewal, got %d", renewed)
+	}
+
+	stored, err := emailVerificationRepository.FindByEmail(ctx, "a@example.com", domain.EmailVerificationPurposeSignup)
+	if err != nil || stored.CodeHash != "new" || stored.Cooldown != 200 {
+		t.Fatalf("expected the renewed verification, got %+v (%v)", stored, err)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"godas/model/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepository interface {
+	FindByIds(context.Context, []string) ([]domain.LoginAttempt, error)
+	Fail(ctx context.Context, id string, expiresAt time.Time) (domain.LoginAttempt, error)
+	Lock(ctx context.Context, id string, until time.Time) error
+	Delete(context.Context, string) error
+}
+
+type LoginAttemptRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewLoginAttemptRepository(db *mongo.Database) LoginAttemptRepository {
+	repository := new(LoginAttemptRepositoryImpl)
+	repository.collection = db.Collection("loginAttempts")
+
+	// Create Index, forgotten counters are removed by MongoDB
+	_, err := repository.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *LoginAttemptRepositoryImpl) FindByIds(ctx context.Context, ids []string) ([]domain.LoginAttempt, error) {
+	loginAttempts := []domain.LoginAttempt{}
+
+	cursor, err := repository.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return loginAttempts, err
+	}
+	if err := cursor.All(ctx, &loginAttempts); err != nil {
+		return loginAttempts, err
+	}
+
+	return loginAttempts, nil
+}
+
+// Count a failure, the counter is created by the first one
+func (repository *LoginAttemptRepositoryImpl) Fail(ctx context.Context, id string, expiresAt time.Time) (domain.LoginAttempt, error) {
+	loginAttempt := domain.LoginAttempt{}
+
+	res := repository.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"expiresAt": expiresAt},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		return loginAttempt, err
+	}
+
+	err := res.Decode(&loginAttempt)
+	return loginAttempt, err
+}
+
+func (repository *LoginAttemptRepositoryImpl) Lock(ctx context.Context, id string, until time.Time) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"lockedUntil": until}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Forget the failures of a key, it is not an error when there were none
+func (repository *LoginAttemptRepositoryImpl) Delete(ctx context.Context, id string) error {
+	_, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
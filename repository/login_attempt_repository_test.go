@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"context"
+	"godas/repository"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptRepository(t *testing.T) {
+	loginAttemptRepository := repository.NewLoginAttemptRepository(newTestDatabase(t))
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for i := 1; i <= 3; i++ {
+		loginAttempt, err := loginAttemptRepository.Fail(ctx, "account:a@example.com", expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loginAttempt.Failures != i {
+			t.Fatalf("expected %d failures, got %+v", i, loginAttempt)
+		}
+	}
+	if _, err := loginAttemptRepository.Fail(ctx, "ip:10.0.0.1", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	lockedUntil := time.Now().Add(time.Minute).UTC().Truncate(time.Millisecond)
+	if err := loginAttemptRepository.Lock(ctx, "account:a@example.com", lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+
+	loginAttempts, err := loginAttemptRepository.FindByIds(ctx, []string{"account:a@example.com", "ip:10.0.0.1", "ip:10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loginAttempts) != 2 {
+		t.Fatalf("expected 2 counters, got %+v", loginAttempts)
+	}
+	for _, loginAttempt := range loginAttempts {
+		if loginAttempt.ID == "account:a@example.com" && !loginAttempt.LockedUntil.Equal(lockedUntil) {
+			t.Fatalf("expected the account to be locked until %v, got %+v", lockedUntil, loginAttempt)
+		}
+	}
+
+	if err := loginAttemptRepository.Delete(ctx, "account:a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loginAttemptRepository.Delete(ctx, "account:a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	loginAttempts, err = loginAttemptRepository.FindByIds(ctx, []string{"account:a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loginAttempts) != 0 {
+		t.Fatalf("expected the counter to be deleted, got %+v", loginAttempts)
+	}
+}
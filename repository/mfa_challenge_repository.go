@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MFAChallengeRepository interface {
+	Insert(context.Context, domain.MFAChallenge) error
+	Attempt(ctx context.Context, id string, maxAttempts int) (domain.MFAChallenge, error)
+	Delete(context.Context, string) error
+}
+
+type MFAChallengeRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewMFAChallengeRepository(db *mongo.Database) MFAChallengeRepository {
+	repository := new(MFAChallengeRepositoryImpl)
+	repository.collection = db.Collection("mfaChallenges")
+
+	// Create Index, expired challenges are removed by MongoDB
+	_, err := repository.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *MFAChallengeRepositoryImpl) Insert(ctx context.Context, challenge domain.MFAChallenge) error {
+	_, err := repository.collection.InsertOne(ctx, challenge)
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return ErrDuplicateData
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Count an attempt at a challenge that has attempts left, expired challenges are only removed periodically
+func (repository *MFAChallengeRepositoryImpl) Attempt(ctx context.Context, id string, maxAttempts int) (domain.MFAChallenge, error) {
+	challenge := domain.MFAChallenge{}
+
+	res := repository.collection.FindOneAndUpdate(ctx, bson.M{
+		"_id":      id,
+		"attempts": bson.M{"$lt": maxAttempts},
+	}, bson.M{"$inc": bson.M{"attempts": 1}}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return challenge, ErrNoData
+		}
+		return challenge, err
+	}
+
+	err := res.Decode(&challenge)
+	return challenge, err
+}
+
+func (repository *MFAChallengeRepositoryImpl) Delete(ctx context.Context, id string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount < 1 {
+		return ErrNoData
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderedMapRepository interface {
+	Insert(context.Context, domain.OrderedMap) (domain.OrderedMap, error)
+	FindById(context.Context, string) (domain.OrderedMap, error)
+	FindByOwner(context.Context, string) ([]domain.OrderedMap, error)
+	FindAll(context.Context) ([]domain.OrderedMap, error)
+	Update(context.Context, domain.OrderedMap) (domain.OrderedMap, error)
+	Delete(context.Context, string) error
+}
+
+type OrderedMapRepositoryImpl struct {
+	collection    *mongo.Collection
+	snowflakeNode *snowflake.Node
+}
+
+func NewOrderedMapRepository(db *mongo.Database, snowflakeNode *snowflake.Node) OrderedMapRepository {
+	repository := new(OrderedMapRepositoryImpl)
+	repository.collection = db.Collection("orderedMaps")
+	repository.snowflakeNode = snowflakeNode
+
+	return repository
+}
+
+func (repository *OrderedMapRepositoryImpl) Insert(ctx context.Context, orderedMap domain.OrderedMap) (domain.OrderedMap, error) {
+	orderedMap.ID = repository.snowflakeNode.Generate().String()
+
+	_, err := repository.collection.InsertOne(ctx, orderedMap)
+	if err != nil {
+		if err, isWriteError := err.(mongo.WriteException); isWriteError && err.HasErrorCode(11000) {
+			return orderedMap, ErrDuplicateData
+		}
+		return orderedMap, err
+	}
+
+	return orderedMap, err
+}
+
+func (repository *OrderedMapRepositoryImpl) FindById(ctx context.Context, id string) (domain.OrderedMap, error) {
+	orderedMap := domain.OrderedMap{}
+
+	result := repository.collection.FindOne(ctx, bson.M{"_id": id})
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return orderedMap, ErrNoData
+		}
+		return orderedMap, err
+	}
+
+	if err := result.Decode(&orderedMap); err != nil {
+		return orderedMap, err
+	}
+
+	return orderedMap, nil
+}
+
+func (repository *OrderedMapRepositoryImpl) FindByOwner(ctx context.Context, owner string) ([]domain.OrderedMap, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{"owner": owner})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoData
+		}
+		return nil, err
+	}
+
+	orderedMaps := []domain.OrderedMap{}
+	if err := cursor.All(ctx, &orderedMaps); err != nil {
+		return nil, err
+	}
+
+	return orderedMaps, nil
+}
+
+func (repository *OrderedMapRepositoryImpl) FindAll(ctx context.Context) ([]domain.OrderedMap, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	orderedMaps := []domain.OrderedMap{}
+	if err := cursor.All(ctx, &orderedMaps); err != nil {
+		return nil, err
+	}
+
+	return orderedMaps, nil
+}
+
+// Replace the nodes of the map only if it still holds the version it was read with,
+// ErrStaleData is returned when it was updated in the meantime
+func (repository *OrderedMapRepositoryImpl) Update(ctx context.Context, orderedMap domain.OrderedMap) (domain.OrderedMap, error) {
+	res, err := repository.collection.UpdateOne(ctx, versionFilter(orderedMap.ID, orderedMap.Owner, orderedMap.Version), bson.M{
+		"$set": bson.M{"nodes": orderedMap.Nodes},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return orderedMap, err
+	}
+	if res.MatchedCount == 0 {
+		count, err := repository.collection.CountDocuments(ctx, bson.M{"_id": orderedMap.ID, "owner": orderedMap.Owner})
+		if err != nil {
+			return orderedMap, err
+		}
+		if count == 0 {
+			return orderedMap, ErrNoData
+		}
+		return orderedMap, ErrStaleData
+	}
+	orderedMap.Version++
+
+	return orderedMap, nil
+}
+
+func (repository *OrderedMapRepositoryImpl) Delete(ctx context.Context, id string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNoData
+		}
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
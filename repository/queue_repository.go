@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QueueRepository interface {
+	Insert(context.Context, domain.Queue) (domain.Queue, error)
+	FindById(context.Context, string) (domain.Queue, error)
+	FindByIdFromOwner(ctx context.Context, id string, owner string) (domain.Queue, error)
+	FindByOwner(context.Context, string) ([]domain.Queue, error)
+	FindAll(context.Context) ([]domain.Queue, error)
+	Update(context.Context, domain.Queue) (domain.Queue, error)
+	Delete(context.Context, string) error
+	PushItem(ctx context.Context, id string, owner string, item domain.Item) (domain.Item, error)
+	PopFront(ctx context.Context, id string, owner string) (domain.Item, error)
+	FrontItem(ctx context.Context, id string, owner string) (domain.Item, error)
+}
+
+type QueueRepositoryImpl struct {
+	collection    *mongo.Collection
+	snowflakeNode *snowflake.Node
+}
+
+func NewQueueRepository(db *mongo.Database, snowflakeNode *snowflake.Node) QueueRepository {
+	repository := new(QueueRepositoryImpl)
+	repository.collection = db.Collection("queues")
+	repository.snowflakeNode = snowflakeNode
+
+	return repository
+}
+
+func (repository *QueueRepositoryImpl) Insert(ctx context.Context, queue domain.Queue) (domain.Queue, error) {
+	queue.ID = repository.snowflakeNode.Generate().String()
+
+	_, err := repository.collection.InsertOne(ctx, queue)
+	if err != nil {
+		if err, isWriteError := err.(mongo.WriteException); isWriteError && err.HasErrorCode(11000) {
+			return queue, ErrDuplicateData
+		}
+		return queue, err
+	}
+
+	return queue, err
+}
+
+func (repository *QueueRepositoryImpl) FindById(ctx context.Context, id string) (domain.Queue, error) {
+	queue := domain.Queue{}
+
+	result := repository.collection.FindOne(ctx, bson.M{"_id": id})
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return queue, ErrNoData
+		}
+		return queue, err
+	}
+
+	if err := result.Decode(&queue); err != nil {
+		return queue, err
+	}
+
+	return queue, nil
+}
+
+func (repository *QueueRepositoryImpl) FindByIdFromOwner(ctx context.Context, id string, owner string) (domain.Queue, error) {
+	queue := domain.Queue{}
+
+	if err := repository.collection.FindOne(ctx, queueFilter(id, owner)).Decode(&queue); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return queue, ErrNoData
+		}
+		return queue, err
+	}
+
+	return queue, nil
+}
+
+func (repository *QueueRepositoryImpl) FindByOwner(ctx context.Context, owner string) ([]domain.Queue, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{"owner": owner})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoData
+		}
+		return nil, err
+	}
+
+	queues := []domain.Queue{}
+	if err := cursor.All(ctx, &queues); err != nil {
+		return nil, err
+	}
+
+	return queues, nil
+}
+
+func (repository *QueueRepositoryImpl) FindAll(ctx context.Context) ([]domain.Queue, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	queues := []domain.Queue{}
+	if err := cursor.All(ctx, &queues); err != nil {
+		return nil, err
+	}
+
+	return queues, nil
+}
+
+func (repository *QueueRepositoryImpl) Update(ctx context.Context, queue domain.Queue) (domain.Queue, error) {
+	res, err := repository.collection.UpdateByID(ctx, queue.ID, bson.M{"$set": queue})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return queue, ErrNoData
+		}
+		return queue, err
+	}
+	if res.MatchedCount == 0 {
+		return queue, ErrNoData
+	}
+
+	return queue, nil
+}
+
+func (repository *QueueRepositoryImpl) Delete(ctx context.Context, id string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNoData
+		}
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Filter a queue by id, an empty owner matches a queue of any owner
+func queueFilter(id string, owner string) bson.M {
+	filter := bson.M{"_id": id}
+	if owner != "" {
+		filter["owner"] = owner
+	}
+	return filter
+}
+
+// Push an item to the back in a single update, the index is assigned by the database.
+// Queues stored before indexes were counted have as many indexes in use as they hold items.
+func (repository *QueueRepositoryImpl) PushItem(ctx context.Context, id string, owner string, item domain.Item) (domain.Item, error) {
+	next := bson.M{"$ifNull": bson.A{"$next", bson.M{"$size": "$items"}}}
+	pushed := bson.M{
+		"index": next,
+		"name":  bson.M{"$literal": item.Name},
+	}
+	if item.Value != nil {
+		pushed["value"] = bson.M{"$literal": item.Value}
+	}
+	if item.HasValue {
+		pushed["hasValue"] = true
+	}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"items": bson.M{"$concatArrays": bson.A{"$items", bson.A{pushed}}},
+			"next":  bson.M{"$add": bson.A{next, 1}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"items": bson.M{"$slice": -1}})
+
+	queue := domain.Queue{}
+	if err := repository.collection.FindOneAndUpdate(ctx, queueFilter(id, owner), update, opts).Decode(&queue); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return item, ErrNoData
+		}
+		return item, err
+	}
+
+	return queue.Items[0], nil
+}
+
+// Remove the front item in a single update, returning the item as it was before removal
+func (repository *QueueRepositoryImpl) PopFront(ctx context.Context, id string, owner string) (domain.Item, error) {
+	filter := queueFilter(id, owner)
+	filter["items.0"] = bson.M{"$exists": true}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"items": bson.M{"$slice": 1}})
+
+	queue := domain.Queue{}
+	if err := repository.collection.FindOneAndUpdate(ctx, filter, bson.M{"$pop": bson.M{"items": -1}}, opts).Decode(&queue); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			count, err := repository.collection.CountDocuments(ctx, queueFilter(id, owner))
+			if err != nil {
+				return domain.Item{}, err
+			}
+			if count == 0 {
+				return domain.Item{}, ErrNoData
+			}
+			return domain.Item{}, ErrEmptyData
+		}
+		return domain.Item{}, err
+	}
+
+	return queue.Items[0], nil
+}
+
+// Read only the front item of a queue
+func (repository *QueueRepositoryImpl) FrontItem(ctx context.Context, id string, owner string) (domain.Item, error) {
+	opts := options.FindOne().SetProjection(bson.M{"items": bson.M{"$slice": 1}})
+
+	queue := domain.Queue{}
+	if err := repository.collection.FindOne(ctx, queueFilter(id, owner), opts).Decode(&queue); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, ErrNoData
+		}
+		return domain.Item{}, err
+	}
+	if len(queue.Items) < 1 {
+		return domain.Item{}, ErrEmptyData
+	}
+
+	return queue.Items[0], nil
+}
@@ -0,0 +1,246 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"godas/model/domain"
+	"godas/repository"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newTestQueueRepository(t *testing.T) repository.QueueRepository {
+	t.Helper()
+
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repository.NewQueueRepository(newTestDatabase(t), snowflakeNode)
+}
+
+func TestQueueRepositoryFIFO(t *testing.T) {
+	queueRepository := newTestQueueRepository(t)
+	ctx := context.Background()
+
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range []string{"a", "b", "c"} {
+		item, err := queueRepository.PushItem(ctx, queue.ID, "owner", domain.Item{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != name || item.Index != uint64(i) {
+			t.Fatalf("expected %s at index %d, got %v", name, i, item)
+		}
+	}
+
+	item, err := queueRepository.PopFront(ctx, queue.ID, "owner")
+	if err != nil || item.Name != "a" || item.Index != 0 {
+		t.Fatalf("expected a at index 0, got %v (%v)", item, err)
+	}
+
+	// Indexes keep increasing after the front is removed
+	item, err = queueRepository.PushItem(ctx, queue.ID, "owner", domain.Item{Name: "d"})
+	if err != nil || item.Index != 3 {
+		t.Fatalf("expected d at index 3, got %v (%v)", item, err)
+	}
+
+	for _, name := range []string{"b", "c", "d"} {
+		item, err := queueRepository.PopFront(ctx, queue.ID, "owner")
+		if err != nil || item.Name != name {
+			t.Fatalf("expected %s, got %v (%v)", name, item, err)
+		}
+	}
+}
+
+func TestQueueRepositoryLegacyIndexes(t *testing.T) {
+	db := newTestDatabase(t)
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queueRepository := repository.NewQueueRepository(db, snowflakeNode)
+	ctx := context.Background()
+
+	// Queues stored before indexes were counted have none
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{{Index: 0, Name: "a"}, {Index: 1, Name: "b"}}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Collection("queues").UpdateByID(ctx, queue.ID, bson.M{"$unset": bson.M{"next": ""}}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := queueRepository.PushItem(ctx, queue.ID, "owner", domain.Item{Name: "c"})
+	if err != nil || item.Index != 2 {
+		t.Fatalf("expected c at index 2, got %v (%v)", item, err)
+	}
+}
+
+func TestQueueRepositoryOwnerFilter(t *testing.T) {
+	queueRepository := newTestQueueRepository(t)
+	ctx := context.Background()
+
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queueRepository.PushItem(ctx, queue.ID, "intruder", domain.Item{Name: "x"}); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := queueRepository.PushItem(ctx, queue.ID, "", domain.Item{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queueRepository.PopFront(ctx, queue.ID, "intruder"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := queueRepository.PopFront(ctx, queue.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueueRepositoryPopEmpty(t *testing.T) {
+	queueRepository := newTestQueueRepository(t)
+	ctx := context.Background()
+
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queueRepository.PopFront(ctx, queue.ID, "owner"); err != repository.ErrEmptyData {
+		t.Fatalf("expected ErrEmptyData, got %v", err)
+	}
+	if _, err := queueRepository.PopFront(ctx, "missing", "owner"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestQueueRepositoryConcurrentEnqueueDequeue(t *testing.T) {
+	queueRepository := newTestQueueRepository(t)
+	ctx := context.Background()
+
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 32, 25
+	total := workers * perWorker
+
+	pushed := make(chan domain.Item, total)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				item, err := queueRepository.PushItem(ctx, queue.ID, "owner", domain.Item{Name: fmt.Sprintf("%d-%d", w, i)})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				pushed <- item
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(pushed)
+
+	indexes := map[uint64]bool{}
+	for item := range pushed {
+		if indexes[item.Index] {
+			t.Fatalf("index %d was assigned twice", item.Index)
+		}
+		indexes[item.Index] = true
+	}
+
+	queue, err = queueRepository.FindById(ctx, queue.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Items) != total {
+		t.Fatalf("expected %d items, got %d", total, len(queue.Items))
+	}
+	for i, item := range queue.Items {
+		if item.Index != uint64(i) {
+			t.Fatalf("expected index %d at position %d, got %d", i, i, item.Index)
+		}
+	}
+
+	// Dequeue more than was enqueued, every item must come out exactly once
+	popped := make(chan domain.Item, total)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker+1; i++ {
+				item, err := queueRepository.PopFront(ctx, queue.ID, "owner")
+				if err != nil {
+					continue
+				}
+				popped <- item
+			}
+		}()
+	}
+	wg.Wait()
+	close(popped)
+
+	names := map[string]bool{}
+	for item := range popped {
+		if names[item.Name] {
+			t.Fatalf("item %s was dequeued twice", item.Name)
+		}
+		names[item.Name] = true
+	}
+	if len(names) != total {
+		t.Fatalf("expected %d dequeued items, got %d", total, len(names))
+	}
+}
+
+func TestQueueRepositoryFrontItem(t *testing.T) {
+	queueRepository := newTestQueueRepository(t)
+	ctx := context.Background()
+
+	queue, err := queueRepository.Insert(ctx, domain.Queue{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queueRepository.FrontItem(ctx, queue.ID, "owner"); err != repository.ErrEmptyData {
+		t.Fatalf("expected ErrEmptyData, got %v", err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := queueRepository.PushItem(ctx, queue.ID, "owner", domain.Item{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	item, err := queueRepository.FrontItem(ctx, queue.ID, "owner")
+	if err != nil || item.Name != "a" {
+		t.Fatalf("expected a, got %v (%v)", item, err)
+	}
+	if _, err := queueRepository.FrontItem(ctx, queue.ID, "intruder"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+
+	found, err := queueRepository.FindByIdFromOwner(ctx, queue.ID, "owner")
+	if err != nil || len(found.Items) != 2 {
+		t.Fatalf("expected the queue with both items, got %v (%v)", found, err)
+	}
+	if _, err := queueRepository.FindByIdFromOwner(ctx, queue.ID, "intruder"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := queueRepository.FindByIdFromOwner(ctx, queue.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+}
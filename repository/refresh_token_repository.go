@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RefreshTokenRepository interface {
+	Insert(context.Context, domain.RefreshToken) error
+	FindById(context.Context, string) (domain.RefreshToken, error)
+	Use(context.Context, string) (domain.RefreshToken, error)
+	DeleteByFamily(context.Context, string) error
+	DeleteByUser(context.Context, string) error
+}
+
+type RefreshTokenRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewRefreshTokenRepository(db *mongo.Database) RefreshTokenRepository {
+	repository := new(RefreshTokenRepositoryImpl)
+	repository.collection = db.Collection("refreshTokens")
+
+	// Create Index, expired tokens are removed by MongoDB
+	_, err := repository.collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.M{"family": 1},
+		},
+		{
+			Keys: bson.M{"userId": 1},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *RefreshTokenRepositoryImpl) Insert(ctx context.Context, refreshToken domain.RefreshToken) error {
+	_, err := repository.collection.InsertOne(ctx, refreshToken)
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return ErrDuplicateData
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (repository *RefreshTokenRepositoryImpl) FindById(ctx context.Context, id string) (domain.RefreshToken, error) {
+	refreshToken := domain.RefreshToken{}
+
+	if err := repository.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&refreshToken); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return refreshToken, ErrNoData
+		}
+		return refreshToken, err
+	}
+
+	return refreshToken, nil
+}
+
+// Mark a token as used in a single update so it can only be exchanged once.
+// A token that was already used is returned with ErrUsedData.
+func (repository *RefreshTokenRepositoryImpl) Use(ctx context.Context, id string) (domain.RefreshToken, error) {
+	refreshToken := domain.RefreshToken{}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	err := repository.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"used": true}}, opts).Decode(&refreshToken)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return refreshToken, ErrNoData
+		}
+		return refreshToken, err
+	}
+	if refreshToken.Used {
+		return refreshToken, ErrUsedData
+	}
+
+	return refreshToken, nil
+}
+
+func (repository *RefreshTokenRepositoryImpl) DeleteByFamily(ctx context.Context, family string) error {
+	_, err := repository.collection.DeleteMany(ctx, bson.M{"family": family})
+
+	return err
+}
+
+func (repository *RefreshTokenRepositoryImpl) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := repository.collection.DeleteMany(ctx, bson.M{"userId": userID})
+
+	return err
+}
@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"context"
+	"godas/model/domain"
+	"godas/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenRepositoryUseOnce(t *testing.T) {
+	refreshTokenRepository := repository.NewRefreshTokenRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	if err := refreshTokenRepository.Insert(ctx, domain.RefreshToken{
+		ID:        "hash",
+		Family:    "family",
+		UserID:    "user",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent exchanges of the same token must succeed exactly once
+	const workers = 16
+	results := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := refreshTokenRepository.Use(ctx, "hash")
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	used := 0
+	for err := range results {
+		if err == nil {
+			used++
+		} else if err != repository.ErrUsedData {
+			t.Fatalf("expected ErrUsedData, got %v", err)
+		}
+	}
+	if used != 1 {
+		t.Fatalf("expected the token to be used once, got %d", used)
+	}
+
+	if refreshToken, err := refreshTokenRepository.Use(ctx, "hash"); err != repository.ErrUsedData || refreshToken.Family != "family" {
+		t.Fatalf("expected the family of a reused token, got %q (%v)", refreshToken.Family, err)
+	}
+	if err := refreshTokenRepository.DeleteByFamily(ctx, "family"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refreshTokenRepository.Use(ctx, "hash"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
@@ -3,4 +3,9 @@ package repository
 import "errors"
 
 var ErrDuplicateData = errors.New("duplicate data")
+var ErrEmptyData = errors.New("empty data")
+var ErrFullData = errors.New("full data")
+var ErrLockedData = errors.New("locked data")
 var ErrNoData = errors.New("no data")
+var ErrStaleData = errors.New("stale data")
+var ErrUsedData = errors.New("used data")
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevokedTokenRepository interface {
+	Insert(context.Context, domain.RevokedToken) error
+	Exists(context.Context, string) (bool, error)
+}
+
+type RevokedTokenRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewRevokedTokenRepository(db *mongo.Database) RevokedTokenRepository {
+	repository := new(RevokedTokenRepositoryImpl)
+	repository.collection = db.Collection("revokedTokens")
+
+	// Create Index, a token is forgotten once it expired on its own
+	_, err := repository.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+// Revoking a token twice is not an error
+func (repository *RevokedTokenRepositoryImpl) Insert(ctx context.Context, revokedToken domain.RevokedToken) error {
+	_, err := repository.collection.InsertOne(ctx, revokedToken)
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (repository *RevokedTokenRepositoryImpl) Exists(ctx context.Context, id string) (bool, error) {
+	err := repository.collection.FindOne(ctx, bson.M{"_id": id}).Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,29 @@
+package repository_test
+
+import (
+	"context"
+	"godas/model/domain"
+	"godas/repository"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokenRepository(t *testing.T) {
+	revokedTokenRepository := repository.NewRevokedTokenRepository(newTestDatabase(t))
+	ctx := context.Background()
+
+	if revoked, err := revokedTokenRepository.Exists(ctx, "jti"); err != nil || revoked {
+		t.Fatalf("expected the token not to be revoked, got %v (%v)", revoked, err)
+	}
+
+	revokedToken := domain.RevokedToken{ID: "jti", UserID: "user", ExpiresAt: time.Now().Add(time.Minute)}
+	for i := 0; i < 2; i++ {
+		if err := revokedTokenRepository.Insert(ctx, revokedToken); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if revoked, err := revokedTokenRepository.Exists(ctx, "jti"); err != nil || !revoked {
+		t.Fatalf("expected the token to be revoked, got %v (%v)", revoked, err)
+	}
+}
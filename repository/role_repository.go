@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepository interface {
+	FindById(context.Context, string) (domain.Role, error)
+	FindAll(context.Context) ([]domain.Role, error)
+	Put(context.Context, domain.Role) (domain.Role, error)
+	Delete(context.Context, string) error
+}
+
+type RoleRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewRoleRepository(db *mongo.Database) RoleRepository {
+	repository := new(RoleRepositoryImpl)
+	repository.collection = db.Collection("roles")
+
+	// Create built-in roles, admin is given every permission known to this version
+	_, err := repository.collection.UpdateByID(context.Background(), domain.RoleAdmin, bson.M{
+		"$set": bson.M{"permissions": domain.Permissions, "builtIn": true},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		panic(err)
+	}
+	_, err = repository.collection.UpdateByID(context.Background(), domain.RoleClient, bson.M{
+		"$set":         bson.M{"builtIn": true},
+		"$setOnInsert": bson.M{"permissions": []domain.Permission{}},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *RoleRepositoryImpl) FindById(ctx context.Context, id string) (domain.Role, error) {
+	role := domain.Role{}
+
+	res := repository.collection.FindOne(ctx, bson.M{"_id": id})
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return role, ErrNoData
+		}
+		return role, err
+	}
+
+	err := res.Decode(&role)
+	return role, err
+}
+
+func (repository *RoleRepositoryImpl) FindAll(ctx context.Context) ([]domain.Role, error) {
+	cur, err := repository.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []domain.Role{}
+	err = cur.All(ctx, &roles)
+
+	return roles, err
+}
+
+// Create the role or replace its permissions
+func (repository *RoleRepositoryImpl) Put(ctx context.Context, role domain.Role) (domain.Role, error) {
+	res := repository.collection.FindOneAndUpdate(ctx, bson.M{"_id": role.ID}, bson.M{
+		"$set":         bson.M{"permissions": role.Permissions},
+		"$setOnInsert": bson.M{"builtIn": false},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		return role, err
+	}
+
+	err := res.Decode(&role)
+	return role, err
+}
+
+func (repository *RoleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount < 1 {
+		return ErrNoData
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"godas/model/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigningKeyRepository interface {
+	Insert(context.Context, domain.SigningKey) error
+	FindUnexpired(context.Context) ([]domain.SigningKey, error)
+}
+
+type SigningKeyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewSigningKeyRepository(db *mongo.Database) SigningKeyRepository {
+	repository := new(SigningKeyRepositoryImpl)
+	repository.collection = db.Collection("signingKeys")
+
+	// Create Index, keys are removed once no token signed with them can still be valid
+	_, err := repository.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return repository
+}
+
+func (repository *SigningKeyRepositoryImpl) Insert(ctx context.Context, signingKey domain.SigningKey) error {
+	_, err := repository.collection.InsertOne(ctx, signingKey)
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return ErrDuplicateData
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Newest keys first
+func (repository *SigningKeyRepositoryImpl) FindUnexpired(ctx context.Context) ([]domain.SigningKey, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := repository.collection.Find(ctx, bson.M{"expiresAt": bson.M{"$gt": time.Now()}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKeys := []domain.SigningKey{}
+	if err := cursor.All(ctx, &signingKeys); err != nil {
+		return nil, err
+	}
+
+	return signingKeys, nil
+}
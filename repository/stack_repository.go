@@ -3,20 +3,53 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"godas/model/domain"
+	"regexp"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type StackRepository interface {
 	Insert(context.Context, domain.Stack) (domain.Stack, error)
 	FindById(context.Context, string) (domain.Stack, error)
 	FindByOwner(context.Context, string) ([]domain.Stack, error)
+	Find(context.Context, StackFilter) ([]domain.Stack, error)
+	FindSummaries(context.Context, StackFilter) ([]domain.StackSummary, error)
 	FindAll(context.Context) ([]domain.Stack, error)
 	Update(context.Context, domain.Stack) (domain.Stack, error)
-	Delete(context.Context, string) error
+	Delete(ctx context.Context, id string, owner string) error
+	PushItem(ctx context.Context, id string, owner string, item domain.Item) (domain.Item, error)
+	PushItems(ctx context.Context, id string, owner string, items []domain.Item) ([]domain.Item, error)
+	PopItem(ctx context.Context, id string, owner string) (domain.Item, error)
+	PopItems(ctx context.Context, id string, owner string, count int) ([]domain.Item, error)
+	TopItem(ctx context.Context, id string, owner string) (domain.Item, error)
+	Size(ctx context.Context, id string, owner string) (uint64, error)
+	Clear(ctx context.Context, id string, owner string) error
+	FindSettings(ctx context.Context, id string, owner string) (domain.Stack, error)
+	FindItems(ctx context.Context, id string, owner string, window ItemWindow) ([]domain.Item, uint64, error)
+	SetSchema(ctx context.Context, id string, owner string, schema string) (domain.Stack, error)
+}
+
+// Empty fields of a filter match any stack.
+// After and Limit are only used to page through summaries ordered by id.
+type StackFilter struct {
+	Owner      string
+	Tag        string
+	NamePrefix string
+	After      string
+	Limit      int
+}
+
+// Offset and Limit count items from the top of the stack, or from the bottom when FromTop is false
+type ItemWindow struct {
+	FromTop bool
+	Offset  int
+	Limit   int
 }
 
 type StackRepositoryImpl struct {
@@ -29,11 +62,25 @@ func NewStackRepository(db *mongo.Database, snowflakeNode *snowflake.Node) Stack
 	repository.collection = db.Collection("stacks")
 	repository.snowflakeNode = snowflakeNode
 
+	// Create Index
+	_, err := repository.collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "owner", Value: 1},
+			{Key: "tags", Value: 1},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
 	return repository
 }
 
 func (repository *StackRepositoryImpl) Insert(ctx context.Context, stack domain.Stack) (domain.Stack, error) {
 	stack.ID = repository.snowflakeNode.Generate().String()
+	// BSON dates only hold milliseconds
+	stack.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	stack.UpdatedAt = stack.CreatedAt
 
 	_, err := repository.collection.InsertOne(ctx, stack)
 	if err != nil {
@@ -65,22 +112,71 @@ func (repository *StackRepositoryImpl) FindById(ctx context.Context, id string)
 }
 
 func (repository *StackRepositoryImpl) FindByOwner(ctx context.Context, owner string) ([]domain.Stack, error) {
-	cursor, err := repository.collection.Find(ctx, bson.M{"owner": owner})
+	return repository.Find(ctx, StackFilter{Owner: owner})
+}
+
+func (repository *StackRepositoryImpl) Find(ctx context.Context, stackFilter StackFilter) ([]domain.Stack, error) {
+	cursor, err := repository.collection.Find(ctx, stackFilter.document())
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, ErrNoData
-		}
 		return nil, err
 	}
 
 	stacks := []domain.Stack{}
-	if err := cursor.All(context.Background(), &stacks); err != nil {
+	if err := cursor.All(ctx, &stacks); err != nil {
 		return nil, err
 	}
 
 	return stacks, nil
 }
 
+// Page through stacks without their items, the size of each stack is counted on the database side.
+// Snowflake ids of the same length sort by creation time, so the last id of a page is the cursor of the next.
+func (repository *StackRepositoryImpl) FindSummaries(ctx context.Context, stackFilter StackFilter) ([]domain.StackSummary, error) {
+	filter := stackFilter.document()
+	if stackFilter.After != "" {
+		filter["_id"] = bson.M{"$gt": stackFilter.After}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	if stackFilter.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: stackFilter.Limit}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$set", Value: bson.M{"size": bson.M{"$size": "$items"}}}},
+		bson.D{{Key: "$project", Value: bson.M{"items": 0}}},
+	)
+
+	cursor, err := repository.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []domain.StackSummary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+func (stackFilter StackFilter) document() bson.M {
+	filter := bson.M{}
+	if stackFilter.Owner != "" {
+		filter["owner"] = stackFilter.Owner
+	}
+	if stackFilter.Tag != "" {
+		filter["tags"] = stackFilter.Tag
+	}
+	if stackFilter.NamePrefix != "" {
+		filter["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(stackFilter.NamePrefix)}
+	}
+
+	return filter
+}
+
 func (repository *StackRepositoryImpl) FindAll(ctx context.Context) ([]domain.Stack, error) {
 	cursor, err := repository.collection.Find(ctx, bson.M{})
 	if err != nil {
@@ -96,6 +192,8 @@ func (repository *StackRepositoryImpl) FindAll(ctx context.Context) ([]domain.St
 }
 
 func (repository *StackRepositoryImpl) Update(ctx context.Context, stack domain.Stack) (domain.Stack, error) {
+	stack.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
 	res, err := repository.collection.UpdateByID(ctx, stack.ID, bson.M{"$set": stack})
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -110,8 +208,9 @@ func (repository *StackRepositoryImpl) Update(ctx context.Context, stack domain.
 	return stack, nil
 }
 
-func (repository *StackRepositoryImpl) Delete(ctx context.Context, id string) error {
-	res, err := repository.collection.DeleteOne(ctx, id)
+// An empty owner deletes a stack of any owner
+func (repository *StackRepositoryImpl) Delete(ctx context.Context, id string, owner string) error {
+	res, err := repository.collection.DeleteOne(ctx, stackFilter(id, owner))
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return ErrNoData
@@ -124,3 +223,298 @@ func (repository *StackRepositoryImpl) Delete(ctx context.Context, id string) er
 
 	return nil
 }
+
+// Filter a stack by id, an empty owner matches a stack of any owner
+func stackFilter(id string, owner string) bson.M {
+	filter := bson.M{"_id": id}
+	if owner != "" {
+		filter["owner"] = owner
+	}
+	return filter
+}
+
+// Push an item in a single update, the index is assigned by the database
+func (repository *StackRepositoryImpl) PushItem(ctx context.Context, id string, owner string, item domain.Item) (domain.Item, error) {
+	items, err := repository.PushItems(ctx, id, owner, []domain.Item{item})
+	if err != nil {
+		return item, err
+	}
+
+	return items[0], nil
+}
+
+// Push all items in a single update so a batch is never partially applied.
+// A bounded stack either rejects a batch that does not fit or, with the drop-oldest
+// policy, evicts items from the bottom and reindexes the remaining items.
+func (repository *StackRepositoryImpl) PushItems(ctx context.Context, id string, owner string, items []domain.Item) ([]domain.Item, error) {
+	pushed := bson.A{}
+	for i, item := range items {
+		element := bson.M{
+			"index": bson.M{"$add": bson.A{bson.M{"$size": "$items"}, i}},
+			"name":  bson.M{"$literal": item.Name},
+		}
+		if item.Value != nil {
+			element["value"] = bson.M{"$literal": item.Value}
+		}
+		if item.HasValue {
+			element["hasValue"] = true
+		}
+		pushed = append(pushed, element)
+	}
+
+	filter := stackFilter(id, owner)
+	filter["$or"] = bson.A{
+		bson.M{"maxSize": bson.M{"$exists": false}},
+		bson.M{"overflowPolicy": domain.OverflowPolicyDropOldest, "maxSize": bson.M{"$gte": len(items)}},
+		bson.M{"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$size": "$items"}, len(items)}}, "$maxSize"}}},
+	}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"items":     bson.M{"$concatArrays": bson.A{"$items", pushed}},
+			"updatedAt": "$$NOW",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"items": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$overflowPolicy", domain.OverflowPolicyDropOldest}},
+					bson.M{"$gt": bson.A{bson.M{"$size": "$items"}, "$maxSize"}},
+				}},
+				bson.M{"$let": bson.M{
+					"vars": bson.M{"kept": bson.M{"$slice": bson.A{"$items", bson.M{"$multiply": bson.A{-1, "$maxSize"}}}}},
+					"in": bson.M{"$map": bson.M{
+						"input": bson.M{"$range": bson.A{0, bson.M{"$size": "$$kept"}}},
+						"as":    "i",
+						"in": bson.M{"$mergeObjects": bson.A{
+							bson.M{"$arrayElemAt": bson.A{"$$kept", "$$i"}},
+							bson.M{"index": "$$i"},
+						}},
+					}},
+				}},
+				"$items",
+			}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"items": bson.M{"$slice": -len(items)}})
+
+	stack := domain.Stack{}
+	if err := repository.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stack); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, repository.unmatched(ctx, id, owner, ErrFullData)
+		}
+		return nil, err
+	}
+
+	return stack.Items, nil
+}
+
+// Tell apart a missing stack from one holding too few or too many items after a conditional
+// update matched nothing, reason is returned when the stack exists
+func (repository *StackRepositoryImpl) unmatched(ctx context.Context, id string, owner string, reason error) error {
+	count, err := repository.collection.CountDocuments(ctx, stackFilter(id, owner))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNoData
+	}
+
+	return reason
+}
+
+// Pop the top item in a single update, returning the item as it was before removal
+func (repository *StackRepositoryImpl) PopItem(ctx context.Context, id string, owner string) (domain.Item, error) {
+	items, err := repository.PopItems(ctx, id, owner, 1)
+	if err != nil {
+		return domain.Item{}, err
+	}
+
+	return items[0], nil
+}
+
+// Pop the top count items in a single update, ordered from the top.
+// Nothing is removed if the stack holds fewer than count items.
+func (repository *StackRepositoryImpl) PopItems(ctx context.Context, id string, owner string, count int) ([]domain.Item, error) {
+	filter := stackFilter(id, owner)
+	filter[fmt.Sprintf("items.%d", count-1)] = bson.M{"$exists": true}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"items": bson.M{"$let": bson.M{
+				"vars": bson.M{"remaining": bson.M{"$subtract": bson.A{bson.M{"$size": "$items"}, count}}},
+				"in": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$$remaining", 0}},
+					bson.M{"$slice": bson.A{"$items", "$$remaining"}},
+					bson.A{},
+				}},
+			}},
+			"updatedAt": "$$NOW",
+		}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"items": bson.M{"$slice": -count}})
+
+	stack := domain.Stack{}
+	if err := repository.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stack); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, repository.unmatched(ctx, id, owner, ErrEmptyData)
+		}
+		return nil, err
+	}
+
+	items := make([]domain.Item, 0, len(stack.Items))
+	for i := len(stack.Items) - 1; i >= 0; i-- {
+		items = append(items, stack.Items[i])
+	}
+
+	return items, nil
+}
+
+// Read the top item without loading the rest of the stack
+func (repository *StackRepositoryImpl) TopItem(ctx context.Context, id string, owner string) (domain.Item, error) {
+	opts := options.FindOne().SetProjection(bson.M{"items": bson.M{"$slice": -1}})
+
+	stack := domain.Stack{}
+	if err := repository.collection.FindOne(ctx, stackFilter(id, owner), opts).Decode(&stack); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.Item{}, ErrNoData
+		}
+		return domain.Item{}, err
+	}
+	if len(stack.Items) < 1 {
+		return domain.Item{}, ErrEmptyData
+	}
+
+	return stack.Items[0], nil
+}
+
+// Count the items on the database side
+func (repository *StackRepositoryImpl) Size(ctx context.Context, id string, owner string) (uint64, error) {
+	cursor, err := repository.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: stackFilter(id, owner)}},
+		{{Key: "$project", Value: bson.M{"size": bson.M{"$size": "$items"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return 0, err
+		}
+		return 0, ErrNoData
+	}
+
+	result := struct {
+		Size uint64 `bson:"size"`
+	}{}
+	if err := cursor.Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.Size, nil
+}
+
+func (repository *StackRepositoryImpl) Clear(ctx context.Context, id string, owner string) error {
+	res, err := repository.collection.UpdateOne(ctx, stackFilter(id, owner), bson.M{
+		"$set":         bson.M{"items": bson.A{}},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Read a stack without loading its items
+func (repository *StackRepositoryImpl) FindSettings(ctx context.Context, id string, owner string) (domain.Stack, error) {
+	opts := options.FindOne().SetProjection(bson.M{"items": 0})
+
+	stack := domain.Stack{}
+	if err := repository.collection.FindOne(ctx, stackFilter(id, owner), opts).Decode(&stack); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return stack, ErrNoData
+		}
+		return stack, err
+	}
+
+	return stack, nil
+}
+
+// An empty schema removes the schema of the stack
+func (repository *StackRepositoryImpl) SetSchema(ctx context.Context, id string, owner string, schema string) (domain.Stack, error) {
+	update := bson.M{
+		"$set":         bson.M{"schema": schema},
+		"$currentDate": bson.M{"updatedAt": true},
+	}
+	if schema == "" {
+		update = bson.M{
+			"$unset":       bson.M{"schema": ""},
+			"$currentDate": bson.M{"updatedAt": true},
+		}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	stack := domain.Stack{}
+	if err := repository.collection.FindOneAndUpdate(ctx, stackFilter(id, owner), update, opts).Decode(&stack); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return stack, ErrNoData
+		}
+		return stack, err
+	}
+
+	return stack, nil
+}
+
+// Read a window of items on the database side along with the size of the stack.
+// Items counted from the top are returned top first.
+func (repository *StackRepositoryImpl) FindItems(ctx context.Context, id string, owner string, window ItemWindow) ([]domain.Item, uint64, error) {
+	items := bson.M{"$slice": bson.A{"$items", window.Offset, window.Limit}}
+	if window.FromTop {
+		items = bson.M{"$let": bson.M{
+			"vars": bson.M{"end": bson.M{"$subtract": bson.A{bson.M{"$size": "$items"}, window.Offset}}},
+			"in": bson.M{"$let": bson.M{
+				"vars": bson.M{"start": bson.M{"$max": bson.A{bson.M{"$subtract": bson.A{"$$end", window.Limit}}, 0}}},
+				"in": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$$end", 0}},
+					bson.M{"$reverseArray": bson.M{"$slice": bson.A{"$items", "$$start", bson.M{"$subtract": bson.A{"$$end", "$$start"}}}}},
+					bson.A{},
+				}},
+			}},
+		}}
+	}
+
+	cursor, err := repository.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: stackFilter(id, owner)}},
+		{{Key: "$project", Value: bson.M{"items": items, "size": bson.M{"$size": "$items"}}}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, ErrNoData
+	}
+
+	result := struct {
+		Items []domain.Item `bson:"items"`
+		Size  uint64        `bson:"size"`
+	}{}
+	if err := cursor.Decode(&result); err != nil {
+		return nil, 0, err
+	}
+
+	return result.Items, result.Size, nil
+}
@@ -0,0 +1,548 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"godas/model/domain"
+	"godas/repository"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Connect to a throwaway database, tests are skipped unless MONGO_TEST_URI is set
+func newTestDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	db := client.Database(fmt.Sprintf("godas_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	return db
+}
+
+func newTestStackRepository(t *testing.T) repository.StackRepository {
+	t.Helper()
+
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repository.NewStackRepository(newTestDatabase(t), snowflakeNode)
+}
+
+func TestStackRepositoryConcurrentPushPop(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 32, 25
+	total := workers * perWorker
+
+	pushed := make(chan domain.Item, total)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				item, err := stackRepository.PushItem(ctx, stack.ID, "owner", domain.Item{Name: fmt.Sprintf("%d-%d", w, i)})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				pushed <- item
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(pushed)
+
+	indexes := map[uint64]bool{}
+	for item := range pushed {
+		if indexes[item.Index] {
+			t.Fatalf("index %d was assigned twice", item.Index)
+		}
+		indexes[item.Index] = true
+	}
+
+	stack, err = stackRepository.FindById(ctx, stack.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stack.Items) != total {
+		t.Fatalf("expected %d items, got %d", total, len(stack.Items))
+	}
+	for i, item := range stack.Items {
+		if item.Index != uint64(i) {
+			t.Fatalf("expected index %d at position %d, got %d", i, i, item.Index)
+		}
+	}
+
+	// Pop more than was pushed, every item must come out exactly once
+	popped := make(chan domain.Item, total)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker+1; i++ {
+				item, err := stackRepository.PopItem(ctx, stack.ID, "owner")
+				if err != nil {
+					continue
+				}
+				popped <- item
+			}
+		}()
+	}
+	wg.Wait()
+	close(popped)
+
+	names := map[string]bool{}
+	for item := range popped {
+		if names[item.Name] {
+			t.Fatalf("item %s was popped twice", item.Name)
+		}
+		names[item.Name] = true
+	}
+	if len(names) != total {
+		t.Fatalf("expected %d popped items, got %d", total, len(names))
+	}
+}
+
+func TestStackRepositoryOwnerFilter(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stackRepository.PushItem(ctx, stack.ID, "intruder", domain.Item{Name: "x"}); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+	if _, err := stackRepository.PushItem(ctx, stack.ID, "", domain.Item{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stackRepository.PopItem(ctx, stack.ID, "intruder"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestStackRepositoryPopEmpty(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stackRepository.PopItem(ctx, stack.ID, "owner"); err != repository.ErrEmptyData {
+		t.Fatalf("expected ErrEmptyData, got %v", err)
+	}
+	if _, err := stackRepository.PopItem(ctx, "missing", "owner"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestStackRepositoryTopSizeClear(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stackRepository.TopItem(ctx, stack.ID, "owner"); err != repository.ErrEmptyData {
+		t.Fatalf("expected ErrEmptyData, got %v", err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if _, err := stackRepository.PushItem(ctx, stack.ID, "owner", domain.Item{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	top, err := stackRepository.TopItem(ctx, stack.ID, "owner")
+	if err != nil || top.Name != "c" || top.Index != 2 {
+		t.Fatalf("expected c at index 2, got %v (%v)", top, err)
+	}
+	if size, err := stackRepository.Size(ctx, stack.ID, "owner"); err != nil || size != 3 {
+		t.Fatalf("expected size 3, got %d (%v)", size, err)
+	}
+	if _, err := stackRepository.Size(ctx, stack.ID, "intruder"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+
+	if err := stackRepository.Clear(ctx, stack.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if size, err := stackRepository.Size(ctx, stack.ID, "owner"); err != nil || size != 0 {
+		t.Fatalf("expected size 0, got %d (%v)", size, err)
+	}
+}
+
+func TestStackRepositoryDelete(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stacks := []domain.Stack{}
+	for _, owner := range []string{"owner", "owner", "other"} {
+		stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: owner})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stacks = append(stacks, stack)
+	}
+
+	if err := stackRepository.Delete(ctx, stacks[2].ID, "owner"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData for a stack of another owner, got %v", err)
+	}
+	if err := stackRepository.Delete(ctx, stacks[0].ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stackRepository.Delete(ctx, stacks[0].ID, "owner"); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData on a second delete, got %v", err)
+	}
+
+	remaining, err := stackRepository.FindAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 {
+		t.Fatalf("expected 2 remaining stacks, got %d", len(remaining))
+	}
+	for _, stack := range remaining {
+		if stack.ID == stacks[0].ID {
+			t.Fatal("expected the deleted stack to be gone")
+		}
+	}
+
+	// Without an owner any stack can be deleted
+	if err := stackRepository.Delete(ctx, stacks[2].ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stackRepository.FindById(ctx, stacks[1].ID); err != nil {
+		t.Fatalf("expected the untouched stack to remain, got %v", err)
+	}
+}
+
+func TestStackRepositoryBatch(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushed, err := stackRepository.PushItems(ctx, stack.ID, "owner", []domain.Item{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range pushed {
+		if item.Index != uint64(i) {
+			t.Fatalf("expected index %d, got %d", i, item.Index)
+		}
+	}
+
+	// Popping more than the stack holds must leave it untouched
+	if _, err := stackRepository.PopItems(ctx, stack.ID, "owner", 4); err != repository.ErrEmptyData {
+		t.Fatalf("expected ErrEmptyData, got %v", err)
+	}
+	if size, _ := stackRepository.Size(ctx, stack.ID, "owner"); size != 3 {
+		t.Fatalf("expected size 3, got %d", size)
+	}
+
+	popped, err := stackRepository.PopItems(ctx, stack.ID, "owner", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(popped) != 2 || popped[0].Name != "c" || popped[1].Name != "b" {
+		t.Fatalf("expected c then b, got %v", popped)
+	}
+
+	popped, err = stackRepository.PopItems(ctx, stack.ID, "owner", 1)
+	if err != nil || len(popped) != 1 || popped[0].Name != "a" {
+		t.Fatalf("expected a, got %v (%v)", popped, err)
+	}
+	if size, _ := stackRepository.Size(ctx, stack.ID, "owner"); size != 0 {
+		t.Fatalf("expected size 0, got %d", size)
+	}
+}
+
+func TestStackRepositorySchemaAndValues(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner", Schema: `{"type":"object"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if settings, err := stackRepository.FindSettings(ctx, stack.ID, "owner"); err != nil || settings.Schema != `{"type":"object"}` {
+		t.Fatalf("expected the schema to be stored, got %q (%v)", settings.Schema, err)
+	}
+	if _, err := stackRepository.SetSchema(ctx, stack.ID, "owner", ""); err != nil {
+		t.Fatal(err)
+	}
+	if settings, err := stackRepository.FindSettings(ctx, stack.ID, "owner"); err != nil || settings.Schema != "" {
+		t.Fatalf("expected the schema to be removed, got %q (%v)", settings.Schema, err)
+	}
+	if _, err := stackRepository.SetSchema(ctx, stack.ID, "intruder", ""); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+
+	// Operator-like keys must be stored as they are
+	value := bson.D{{Key: "$set", Value: bson.D{{Key: "job", Value: "$items"}}}}
+	if _, err := stackRepository.PushItem(ctx, stack.ID, "owner", domain.Item{Value: value}); err != nil {
+		t.Fatal(err)
+	}
+
+	top, err := stackRepository.TopItem(ctx, stack.ID, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, isDocument := top.Value.(bson.D)
+	if !isDocument || len(stored) != 1 || stored[0].Key != "$set" {
+		t.Fatalf("expected the value to round trip, got %v", top.Value)
+	}
+
+	// A null value is stored as a flag
+	if _, err := stackRepository.PushItem(ctx, stack.ID, "owner", domain.Item{HasValue: true}); err != nil {
+		t.Fatal(err)
+	}
+	top, err = stackRepository.TopItem(ctx, stack.ID, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top.Value != nil || !top.HasValue {
+		t.Fatalf("expected a null value, got %+v", top)
+	}
+}
+
+func TestStackRepositoryFindAndTimestamps(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	for _, stack := range []domain.Stack{
+		{Name: "jobs.daily", Tags: []string{"jobs", "daily"}, Owner: "owner"},
+		{Name: "jobs.weekly", Tags: []string{"jobs"}, Owner: "owner"},
+		{Name: "jobs.daily", Tags: []string{"jobs"}, Owner: "other"},
+		{Name: "history", Owner: "owner"},
+	} {
+		stack.Items = []domain.Item{}
+		if _, err := stackRepository.Insert(ctx, stack); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		filter   repository.StackFilter
+		expected int
+	}{
+		{repository.StackFilter{Owner: "owner"}, 3},
+		{repository.StackFilter{Owner: "owner", Tag: "jobs"}, 2},
+		{repository.StackFilter{Owner: "owner", Tag: "daily"}, 1},
+		{repository.StackFilter{Owner: "owner", NamePrefix: "jobs."}, 2},
+		{repository.StackFilter{Owner: "owner", NamePrefix: "jobs.d", Tag: "jobs"}, 1},
+		{repository.StackFilter{NamePrefix: "jobs.daily"}, 2},
+		// The prefix is matched literally
+		{repository.StackFilter{NamePrefix: "jobs.*"}, 0},
+	} {
+		stacks, err := stackRepository.Find(ctx, test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stacks) != test.expected {
+			t.Fatalf("expected %d stacks for %+v, got %d", test.expected, test.filter, len(stacks))
+		}
+	}
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stack.CreatedAt.IsZero() || !stack.UpdatedAt.Equal(stack.CreatedAt) {
+		t.Fatalf("expected both timestamps to be set on insert, got %v and %v", stack.CreatedAt, stack.UpdatedAt)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := stackRepository.PushItem(ctx, stack.ID, "owner", domain.Item{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := stackRepository.FindById(ctx, stack.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed.CreatedAt.Equal(stack.CreatedAt) || !pushed.UpdatedAt.After(stack.UpdatedAt) {
+		t.Fatalf("expected only updatedAt to move, got %v and %v", pushed.CreatedAt, pushed.UpdatedAt)
+	}
+}
+
+func TestStackRepositoryBounded(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	rejecting, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner", MaxSize: 2, OverflowPolicy: domain.OverflowPolicyReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stackRepository.PushItems(ctx, rejecting.ID, "owner", []domain.Item{{Name: "a"}, {Name: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stackRepository.PushItem(ctx, rejecting.ID, "owner", domain.Item{Name: "c"}); err != repository.ErrFullData {
+		t.Fatalf("expected ErrFullData, got %v", err)
+	}
+	if _, err := stackRepository.PushItem(ctx, "missing", "owner", domain.Item{Name: "c"}); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+
+	dropping, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner", MaxSize: 3, OverflowPolicy: domain.OverflowPolicyDropOldest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		item, err := stackRepository.PushItem(ctx, dropping.ID, "owner", domain.Item{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "d" && item.Index != 2 {
+			t.Fatalf("expected the evicting push to land at index 2, got %d", item.Index)
+		}
+	}
+	if _, err := stackRepository.PushItems(ctx, dropping.ID, "owner", []domain.Item{{}, {}, {}, {}}); err != repository.ErrFullData {
+		t.Fatalf("expected a batch larger than the stack to be rejected, got %v", err)
+	}
+
+	stack, err := stackRepository.FindById(ctx, dropping.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"b", "c", "d"} {
+		if stack.Items[i].Name != name || stack.Items[i].Index != uint64(i) {
+			t.Fatalf("expected %s at index %d, got %v", name, i, stack.Items[i])
+		}
+	}
+}
+
+func TestStackRepositorySummaries(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	ids := []string{}
+	for i := 0; i < 5; i++ {
+		stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stackRepository.PushItems(ctx, stack.ID, "owner", make([]domain.Item, i+1)); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, stack.ID)
+	}
+
+	// Pages of two stacks must cover every stack once, in order
+	seen := []string{}
+	after := ""
+	for {
+		summaries, err := stackRepository.FindSummaries(ctx, repository.StackFilter{Owner: "owner", After: after, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(summaries) == 0 {
+			break
+		}
+		for _, summary := range summaries {
+			if summary.Items != nil {
+				t.Fatal("expected summaries to be read without items")
+			}
+			seen = append(seen, summary.ID)
+			after = summary.ID
+		}
+	}
+	if len(seen) != len(ids) {
+		t.Fatalf("expected %d stacks, got %d", len(ids), len(seen))
+	}
+	for i, id := range ids {
+		if seen[i] != id {
+			t.Fatalf("expected %s at position %d, got %s", id, i, seen[i])
+		}
+	}
+
+	summaries, err := stackRepository.FindSummaries(ctx, repository.StackFilter{Owner: "owner", Limit: 1, After: ids[2]})
+	if err != nil || len(summaries) != 1 || summaries[0].Size != 4 {
+		t.Fatalf("expected the fourth stack with 4 items, got %v (%v)", summaries, err)
+	}
+}
+
+func TestStackRepositoryFindItems(t *testing.T) {
+	stackRepository := newTestStackRepository(t)
+	ctx := context.Background()
+
+	stack, err := stackRepository.Insert(ctx, domain.Stack{Items: []domain.Item{}, Owner: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stackRepository.PushItems(ctx, stack.ID, "owner", []domain.Item{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		window   repository.ItemWindow
+		expected string
+	}{
+		{repository.ItemWindow{FromTop: true, Offset: 0, Limit: 2}, "ed"},
+		{repository.ItemWindow{FromTop: true, Offset: 3, Limit: 5}, "ba"},
+		{repository.ItemWindow{FromTop: true, Offset: 5, Limit: 1}, ""},
+		{repository.ItemWindow{FromTop: false, Offset: 1, Limit: 2}, "bc"},
+		{repository.ItemWindow{FromTop: false, Offset: 4, Limit: 3}, "e"},
+		{repository.ItemWindow{FromTop: false, Offset: 9, Limit: 3}, ""},
+	} {
+		items, size, err := stackRepository.FindItems(ctx, stack.ID, "owner", test.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := ""
+		for _, item := range items {
+			names += item.Name
+		}
+		if names != test.expected || size != 5 {
+			t.Fatalf("expected %q of 5 items for %+v, got %q of %d", test.expected, test.window, names, size)
+		}
+	}
+
+	if _, _, err := stackRepository.FindItems(ctx, stack.ID, "intruder", repository.ItemWindow{Limit: 1}); err != repository.ErrNoData {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+
+	"github.com/bwmarrin/snowflake"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TreeRepository interface {
+	Insert(context.Context, domain.Tree) (domain.Tree, error)
+	FindById(context.Context, string) (domain.Tree, error)
+	FindByOwner(context.Context, string) ([]domain.Tree, error)
+	FindAll(context.Context) ([]domain.Tree, error)
+	Update(context.Context, domain.Tree) (domain.Tree, error)
+	Delete(context.Context, string) error
+}
+
+type TreeRepositoryImpl struct {
+	collection    *mongo.Collection
+	snowflakeNode *snowflake.Node
+}
+
+func NewTreeRepository(db *mongo.Database, snowflakeNode *snowflake.Node) TreeRepository {
+	repository := new(TreeRepositoryImpl)
+	repository.collection = db.Collection("trees")
+	repository.snowflakeNode = snowflakeNode
+
+	return repository
+}
+
+func (repository *TreeRepositoryImpl) Insert(ctx context.Context, tree domain.Tree) (domain.Tree, error) {
+	tree.ID = repository.snowflakeNode.Generate().String()
+
+	_, err := repository.collection.InsertOne(ctx, tree)
+	if err != nil {
+		if err, isWriteError := err.(mongo.WriteException); isWriteError && err.HasErrorCode(11000) {
+			return tree, ErrDuplicateData
+		}
+		return tree, err
+	}
+
+	return tree, err
+}
+
+func (repository *TreeRepositoryImpl) FindById(ctx context.Context, id string) (domain.Tree, error) {
+	tree := domain.Tree{}
+
+	result := repository.collection.FindOne(ctx, bson.M{"_id": id})
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return tree, ErrNoData
+		}
+		return tree, err
+	}
+
+	if err := result.Decode(&tree); err != nil {
+		return tree, err
+	}
+
+	return tree, nil
+}
+
+func (repository *TreeRepositoryImpl) FindByOwner(ctx context.Context, owner string) ([]domain.Tree, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{"owner": owner})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoData
+		}
+		return nil, err
+	}
+
+	trees := []domain.Tree{}
+	if err := cursor.All(ctx, &trees); err != nil {
+		return nil, err
+	}
+
+	return trees, nil
+}
+
+func (repository *TreeRepositoryImpl) FindAll(ctx context.Context) ([]domain.Tree, error) {
+	cursor, err := repository.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	trees := []domain.Tree{}
+	if err := cursor.All(ctx, &trees); err != nil {
+		return nil, err
+	}
+
+	return trees, nil
+}
+
+// Replace the nodes of the tree only if it still holds the version it was read with,
+// ErrStaleData is returned when it was updated in the meantime
+func (repository *TreeRepositoryImpl) Update(ctx context.Context, tree domain.Tree) (domain.Tree, error) {
+	res, err := repository.collection.UpdateOne(ctx, versionFilter(tree.ID, tree.Owner, tree.Version), bson.M{
+		"$set": bson.M{"nodes": tree.Nodes},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return tree, err
+	}
+	if res.MatchedCount == 0 {
+		count, err := repository.collection.CountDocuments(ctx, bson.M{"_id": tree.ID, "owner": tree.Owner})
+		if err != nil {
+			return tree, err
+		}
+		if count == 0 {
+			return tree, ErrNoData
+		}
+		return tree, ErrStaleData
+	}
+	tree.Version++
+
+	return tree, nil
+}
+
+// Filter a document by id, owner and version, documents stored before they were versioned have none
+func versionFilter(id string, owner string, version uint64) bson.M {
+	filter := bson.M{"_id": id, "owner": owner, "version": version}
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	return filter
+}
+
+func (repository *TreeRepositoryImpl) Delete(ctx context.Context, id string) error {
+	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNoData
+		}
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
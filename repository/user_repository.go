@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"godas/model/domain"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,7 +18,18 @@ type UserRepository interface {
 	FindByEmail(context.Context, string) (domain.User, error)
 	FindAll(context.Context) ([]domain.User, error)
 	Update(context.Context, domain.User) (domain.User, error)
+	SetName(ctx context.Context, id string, name string) (domain.User, error)
+	SetPassword(ctx context.Context, id string, password string) error
+	SetVerified(ctx context.Context, id string) error
+	SetEmail(ctx context.Context, id string, email string) error
+	SetTOTP(ctx context.Context, id string, secret string, recoveryCodes []string) error
+	EnableTOTP(ctx context.Context, id string, step int64) error
+	DisableTOTP(ctx context.Context, id string) error
+	UseTOTPStep(ctx context.Context, id string, step int64) error
+	UseRecoveryCode(ctx context.Context, id string, hash string) error
 	Delete(context.Context, domain.User) error
+	RevokeTokens(ctx context.Context, id string, at time.Time) error
+	SetRoles(ctx context.Context, id string, roles []string, at time.Time) error
 }
 
 type UserRepositoryImpl struct {
@@ -115,6 +127,62 @@ func (repository *UserRepositoryImpl) Update(ctx context.Context, user domain.Us
 	return user, nil
 }
 
+// Only change the name, the rest of the user is left as it is
+func (repository *UserRepositoryImpl) SetName(ctx context.Context, id string, name string) (domain.User, error) {
+	user := domain.User{}
+
+	res := repository.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"name": name}}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err := res.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return user, ErrNoData
+		}
+		return user, err
+	}
+
+	err := res.Decode(&user)
+	return user, err
+}
+
+func (repository *UserRepositoryImpl) SetPassword(ctx context.Context, id string, password string) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"password": password}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+func (repository *UserRepositoryImpl) SetVerified(ctx context.Context, id string) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// The email is unique, taking the address of another user fails with ErrDuplicateData
+func (repository *UserRepositoryImpl) SetEmail(ctx context.Context, id string, email string) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"email": email}})
+	if err != nil {
+		if err, isWriteException := err.(mongo.WriteException); isWriteException && err.HasErrorCode(11000) {
+			return ErrDuplicateData
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
 func (repository *UserRepositoryImpl) Delete(ctx context.Context, user domain.User) error {
 	res, err := repository.collection.DeleteOne(ctx, bson.M{"_id": user.ID})
 	if res.DeletedCount < 1 {
@@ -123,3 +191,112 @@ func (repository *UserRepositoryImpl) Delete(ctx context.Context, user domain.Us
 
 	return err
 }
+
+func (repository *UserRepositoryImpl) RevokeTokens(ctx context.Context, id string, at time.Time) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"tokensRevokedAt": at}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Replace the roles of a user, tokens issued up to at are revoked in the same update.
+// The legacy role is reset so that it never grants anything once roles are assigned.
+func (repository *UserRepositoryImpl) SetRoles(ctx context.Context, id string, roles []string, at time.Time) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"roles":           roles,
+		"role":            domain.UserRoleClient,
+		"tokensRevokedAt": at,
+	}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Store a new secret that is not used until it is enabled, a user with two-factor authentication
+// enabled keeps its secret
+func (repository *UserRepositoryImpl) SetTOTP(ctx context.Context, id string, secret string, recoveryCodes []string) error {
+	res, err := repository.collection.UpdateOne(ctx, bson.M{"_id": id, "totpEnabled": bson.M{"$ne": true}}, bson.M{
+		"$set": bson.M{"totpSecret": secret, "recoveryCodes": recoveryCodes},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Enable two-factor authentication, the step of the code that confirmed it cannot be used again
+func (repository *UserRepositoryImpl) EnableTOTP(ctx context.Context, id string, step int64) error {
+	res, err := repository.collection.UpdateOne(ctx, bson.M{"_id": id, "totpSecret": bson.M{"$exists": true}}, bson.M{
+		"$set": bson.M{"totpEnabled": true, "totpLastStep": step},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+func (repository *UserRepositoryImpl) DisableTOTP(ctx context.Context, id string) error {
+	res, err := repository.collection.UpdateByID(ctx, id, bson.M{
+		"$unset": bson.M{"totpSecret": "", "totpEnabled": "", "totpLastStep": "", "recoveryCodes": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
+
+// Record the step of an accepted code, it fails with ErrUsedData when a code of that step or a later one was used
+func (repository *UserRepositoryImpl) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	res, err := repository.collection.UpdateOne(ctx, bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"totpLastStep": bson.M{"$exists": false}},
+			bson.M{"totpLastStep": bson.M{"$lt": step}},
+		},
+	}, bson.M{"$set": bson.M{"totpLastStep": step}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUsedData
+	}
+
+	return nil
+}
+
+// Remove a recovery code, it fails with ErrNoData when the user has no such code
+func (repository *UserRepositoryImpl) UseRecoveryCode(ctx context.Context, id string, hash string) error {
+	res, err := repository.collection.UpdateOne(ctx, bson.M{"_id": id, "recoveryCodes": hash}, bson.M{
+		"$pull": bson.M{"recoveryCodes": hash},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNoData
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/repository"
+	"testing"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+func TestUserRepositoryPartialUpdates(t *testing.T) {
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepository := repository.NewUserRepository(newTestDatabase(t), snowflakeNode)
+	ctx := context.Background()
+
+	user, err := userRepository.Insert(ctx, domain.User{
+		Name:     "a",
+		Roles:    []string{domain.RoleAdmin},
+		Email:    "a@example.com",
+		Password: "hash",
+		Verified: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := userRepository.Insert(ctx, domain.User{Name: "b", Email: "b@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed, err := userRepository.SetName(ctx, user.ID, "renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name != "renamed" || renamed.Email != "a@example.com" || renamed.Password != "hash" || !renamed.Verified || len(renamed.Roles) != 1 {
+		t.Fatalf("expected only the name to change, got %+v", renamed)
+	}
+
+	if err := userRepository.SetPassword(ctx, user.ID, "other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepository.SetEmail(ctx, user.ID, "b@example.com"); !errors.Is(err, repository.ErrDuplicateData) {
+		t.Fatalf("expected the address of another user to be refused, got %v", err)
+	}
+	if err := userRepository.SetEmail(ctx, user.ID, "c@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := userRepository.FindByEmail(ctx, "c@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != user.ID || found.Name != "renamed" || found.Password != "other" {
+		t.Fatalf("expected the updated user, got %+v", found)
+	}
+}
+
+func TestUserRepositoryTOTP(t *testing.T) {
+	snowflakeNode, err := snowflake.NewNode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userRepository := repository.NewUserRepository(newTestDatabase(t), snowflakeNode)
+	ctx := context.Background()
+
+	user, err := userRepository.Insert(ctx, domain.User{Name: "a", Email: "a@example.com", Verified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := userRepository.SetTOTP(ctx, user.ID, "secret", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepository.EnableTOTP(ctx, user.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepository.SetTOTP(ctx, user.ID, "other", nil); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected an enabled secret to be kept, got %v", err)
+	}
+
+	if err := userRepository.UseTOTPStep(ctx, user.ID, 10); !errors.Is(err, repository.ErrUsedData) {
+		t.Fatalf("expected a used step to be refused, got %v", err)
+	}
+	if err := userRepository.UseTOTPStep(ctx, user.ID, 11); err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepository.UseRecoveryCode(ctx, user.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := userRepository.UseRecoveryCode(ctx, user.ID, "a"); !errors.Is(err, repository.ErrNoData) {
+		t.Fatalf("expected a used recovery code to be refused, got %v", err)
+	}
+
+	found, err := userRepository.FindById(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found.TOTPEnabled || found.TOTPSecret != "secret" || found.TOTPLastStep != 11 || len(found.RecoveryCodes) != 1 {
+		t.Fatalf("expected an enabled secret with one recovery code left, got %+v", found)
+	}
+
+	if err := userRepository.DisableTOTP(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	found, err = userRepository.FindById(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.TOTPEnabled || found.TOTPSecret != "" || len(found.RecoveryCodes) != 0 {
+		t.Fatalf("expected two-factor authentication to be removed, got %+v", found)
+	}
+}
@@ -1,19 +1,51 @@
 package secure
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"godas/model/web"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-const DefaultJWTExpiration = time.Hour * 24 * 30
+// Access tokens are short-lived, sessions are kept alive with refresh tokens
+const DefaultJWTExpiration = time.Minute * 15
+
+// Supported signing algorithms, HS256 signs with a shared secret and the others with rotating key pairs
+const (
+	SigningAlgorithmHS256 = "HS256"
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// How long a key pair signs tokens, and how long it is kept afterwards to verify them
+const (
+	DefaultKeyRotation    = time.Hour * 24 * 30
+	DefaultKeyGracePeriod = time.Hour * 24
+)
+
+// How long clients may cache the published keys, a new key pair is published at least this long
+// before it signs tokens so that clients holding a cached key set can verify them
+const JWKSMaxAge = time.Minute * 5
+
+var ErrUnknownSigningAlgorithm = errors.New("unknown signing algorithm")
 
 type JWTProvider struct {
 	expiration   time.Duration
 	issuer       string
 	signatureKey []byte
+
+	algorithm   string
+	store       SigningKeyStore
+	rotation    time.Duration
+	gracePeriod time.Duration
+	mutex       sync.Mutex
+	keys        []signingKey
+	loadedAt    time.Time
 }
 
 func NewJWTProvider(expiration time.Duration, issuer string, signatureKey string) *JWTProvider {
@@ -21,43 +53,146 @@ func NewJWTProvider(expiration time.Duration, issuer string, signatureKey string
 	jwtProvider.expiration = expiration
 	jwtProvider.issuer = issuer
 	jwtProvider.signatureKey = []byte(signatureKey)
+	jwtProvider.algorithm = SigningAlgorithmHS256
 
 	return jwtProvider
 }
 
-// Create token
+// Sign tokens with key pairs shared through the store, a new key pair is generated every rotation.
+// The grace period is at least the token expiration so that no valid token loses its key.
+func NewRotatingJWTProvider(expiration time.Duration, issuer string, algorithm string, store SigningKeyStore, rotation time.Duration, gracePeriod time.Duration) (*JWTProvider, error) {
+	if algorithm != SigningAlgorithmRS256 && algorithm != SigningAlgorithmEdDSA {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSigningAlgorithm, algorithm)
+	}
+	if gracePeriod < expiration {
+		gracePeriod = expiration
+	}
+
+	jwtProvider := new(JWTProvider)
+	jwtProvider.expiration = expiration
+	jwtProvider.issuer = issuer
+	jwtProvider.algorithm = algorithm
+	jwtProvider.store = store
+	jwtProvider.rotation = rotation
+	jwtProvider.gracePeriod = gracePeriod
+
+	return jwtProvider, nil
+}
+
+func (provider *JWTProvider) Expiration() time.Duration {
+	return provider.expiration
+}
+
+// Create token, every token gets a unique id so it can be revoked on its own
 func (provider *JWTProvider) Token(claims web.JwtClaims) (string, error) {
 	now := time.Now()
 
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	claims.IssuedAtMilli = now.UnixMilli()
 	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        id,
 		Subject:   claims.UserID,
 		IssuedAt:  jwt.NewNumericDate(now),
 		ExpiresAt: jwt.NewNumericDate(now.Add(provider.expiration)),
 		Issuer:    provider.issuer,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if provider.store == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+		return token.SignedString(provider.signatureKey)
+	}
+
+	key, err := provider.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
 
-	return token.SignedString(provider.signatureKey)
+	return token.SignedString(key.private)
 }
 
+// Tokens that are refused are reported with a *jwt.ValidationError, failing to read the keys is not
 func (provider *JWTProvider) Validate(tokenString string) (web.JwtClaims, error) {
 	claims := web.JwtClaims{}
 
+	var storeErr error
 	parsedToken, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		method, isHS256 := token.Method.(*jwt.SigningMethodHMAC)
-		if !isHS256 || method != jwt.SigningMethodHS256 {
+		if provider.store == nil {
+			method, isHS256 := token.Method.(*jwt.SigningMethodHMAC)
+			if !isHS256 || method != jwt.SigningMethodHS256 {
+				return nil, errors.New("invalid signing method")
+			}
+			return provider.signatureKey, nil
+		}
+
+		kid, isString := token.Header["kid"].(string)
+		if !isString {
+			return nil, errors.New("missing key id")
+		}
+		key, err := provider.verificationKey(kid)
+		if err != nil {
+			if !errors.Is(err, ErrUnknownKey) {
+				storeErr = err
+			}
+			return nil, err
+		}
+		// The algorithm is taken from the key, never from the token
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return provider.signatureKey, nil
+		return key.public, nil
 	})
+	if storeErr != nil {
+		return claims, storeErr
+	}
 	if err != nil {
 		return claims, err
 	}
 
 	if !parsedToken.Valid {
-		return claims, errors.New("invalid claims")
+		return claims, jwt.NewValidationError("invalid claims", jwt.ValidationErrorClaimsInvalid)
 	}
 
 	return claims, nil
 }
+
+// Public keys able to verify tokens, empty when tokens are signed with a shared secret
+func (provider *JWTProvider) JWKS() (web.JWKSResponse, error) {
+	response := web.JWKSResponse{Keys: []web.JWK{}}
+
+	if provider.store == nil {
+		return response, nil
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if err := provider.loadIfStale(); err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	for _, key := range provider.keys {
+		if now.Before(key.expiresAt) {
+			response.Keys = append(response.Keys, key.jwk())
+		}
+	}
+
+	return response, nil
+}
+
+func randomID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
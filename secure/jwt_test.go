@@ -0,0 +1,42 @@
+package secure_test
+
+import (
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/secure"
+	"testing"
+	"time"
+)
+
+func TestJWTProviderTokenID(t *testing.T) {
+	provider := secure.NewJWTProvider(time.Minute, "godas", "secret")
+
+	ids := map[string]bool{}
+	for i := 0; i < 16; i++ {
+		token, err := provider.Token(web.JwtClaims{UserID: "user", Roles: []string{domain.RoleAdmin}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		claims, err := provider.Validate(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.UserID != "user" || len(claims.Roles) != 1 || claims.Roles[0] != domain.RoleAdmin || claims.Subject != "user" {
+			t.Fatalf("expected the claims to round trip, got %+v", claims)
+		}
+		if claims.ID == "" || ids[claims.ID] {
+			t.Fatalf("expected a unique token id, got %q", claims.ID)
+		}
+		ids[claims.ID] = true
+	}
+
+	other := secure.NewJWTProvider(time.Minute, "godas", "other")
+	token, err := other.Token(web.JwtClaims{UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Validate(token); err == nil {
+		t.Fatal("expected a token signed with another key to be rejected")
+	}
+}
@@ -0,0 +1,141 @@
+package secure
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported password hashing algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+const DefaultPasswordHashAlgorithm = PasswordHashBcrypt
+
+// bcrypt ignores every byte past the 72nd, longer passwords are refused whatever the algorithm
+// so that switching algorithms never changes which passwords are accepted
+const MaxPasswordLength = 72
+
+// Argon2id parameters, as recommended by RFC 9106 for memory constrained environments
+const (
+	argon2Time      = 3
+	argon2Memory    = 64 * 1024
+	argon2Threads   = 2
+	argon2SaltSize  = 16
+	argon2KeyLength = 32
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash algorithm")
+
+type PasswordHasher struct {
+	algorithm  string
+	bcryptCost int
+}
+
+func NewPasswordHasher(algorithm string) (*PasswordHasher, error) {
+	if algorithm == "" {
+		algorithm = DefaultPasswordHashAlgorithm
+	}
+	if algorithm != PasswordHashBcrypt && algorithm != PasswordHashArgon2id {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPasswordHash, algorithm)
+	}
+
+	passwordHasher := new(PasswordHasher)
+	passwordHasher.algorithm = algorithm
+	passwordHasher.bcryptCost = bcrypt.DefaultCost
+
+	return passwordHasher, nil
+}
+
+// Hash a password with the configured algorithm.
+// Argon2id hashes are encoded in the PHC string format.
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	if hasher.algorithm == PasswordHashArgon2id {
+		salt := make([]byte, argon2SaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLength)
+
+		return fmt.Sprintf(
+			"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hasher.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Compare a password with a stored hash in constant time.
+// Anything that is neither a bcrypt nor an argon2id hash is a legacy plaintext password.
+// Rehash tells that the password matched but the stored value should be replaced by a new hash,
+// because it is plaintext or was made with another algorithm or other parameters.
+func (hasher *PasswordHasher) Verify(hash string, password string) (match bool, rehash bool) {
+	if password == "" {
+		return false, false
+	}
+
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		match, current := verifyArgon2id(hash, password)
+		return match, match && (hasher.algorithm != PasswordHashArgon2id || !current)
+	case strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			return false, false
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return true, hasher.algorithm != PasswordHashBcrypt || err != nil || cost != hasher.bcryptCost
+	default:
+		match := subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+		return match, match
+	}
+}
+
+// Current tells whether the hash was made with the parameters used by Hash
+func verifyArgon2id(hash string, password string) (match bool, current bool) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil || time < 1 || threads < 1 {
+		return false, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, false
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return false, false
+	}
+
+	return true, memory == argon2Memory && time == argon2Time && threads == argon2Threads && len(key) == argon2KeyLength
+}
@@ -0,0 +1,86 @@
+package secure_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"godas/secure"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	for _, algorithm := range []string{secure.PasswordHashBcrypt, secure.PasswordHashArgon2id} {
+		hasher, err := secure.NewPasswordHasher(algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(hash, "correct horse") {
+			t.Fatalf("%s: expected the password not to appear in the hash", algorithm)
+		}
+
+		if match, rehash := hasher.Verify(hash, "correct horse"); !match || rehash {
+			t.Fatalf("%s: expected a current match, got match %v and rehash %v", algorithm, match, rehash)
+		}
+		if match, _ := hasher.Verify(hash, "wrong horse"); match {
+			t.Fatalf("%s: expected a wrong password not to match", algorithm)
+		}
+
+		other, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other == hash {
+			t.Fatalf("%s: expected every hash to be salted", algorithm)
+		}
+	}
+}
+
+func TestPasswordHasherMigration(t *testing.T) {
+	bcryptHasher, _ := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	argon2Hasher, _ := secure.NewPasswordHasher(secure.PasswordHashArgon2id)
+
+	// Legacy plaintext records match once and must be rehashed
+	if match, rehash := bcryptHasher.Verify("plaintext", "plaintext"); !match || !rehash {
+		t.Fatalf("expected plaintext to match and be rehashed, got match %v and rehash %v", match, rehash)
+	}
+	if match, rehash := bcryptHasher.Verify("plaintext", "plaintexT"); match || rehash {
+		t.Fatalf("expected a wrong plaintext password to be rejected, got match %v and rehash %v", match, rehash)
+	}
+
+	// A hash made with another algorithm still matches but is upgraded
+	hash, err := bcryptHasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, rehash := argon2Hasher.Verify(hash, "password"); !match || !rehash {
+		t.Fatalf("expected a bcrypt hash to be rehashed by argon2id, got match %v and rehash %v", match, rehash)
+	}
+
+	// Weaker argon2id parameters are upgraded too
+	salt := []byte("saltsaltsaltsalt")
+	weak := fmt.Sprintf(
+		"$argon2id$v=%d$m=4096,t=1,p=1$%s$%s",
+		argon2.Version, base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("password"), salt, 1, 4096, 1, 32)),
+	)
+	if match, rehash := argon2Hasher.Verify(weak, "password"); !match || !rehash {
+		t.Fatalf("expected weak parameters to be rehashed, got match %v and rehash %v", match, rehash)
+	}
+	if match, _ := argon2Hasher.Verify(weak, "passwort"); match {
+		t.Fatal("expected a wrong password not to match")
+	}
+
+	if _, err := secure.NewPasswordHasher("md5"); err == nil {
+		t.Fatal("expected an unknown algorithm to be rejected")
+	}
+	if match, _ := bcryptHasher.Verify("", ""); match {
+		t.Fatal("expected an empty password never to match")
+	}
+}
@@ -0,0 +1,269 @@
+package secure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"godas/model/domain"
+	"godas/model/web"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Keys are read again after keyCacheDuration, or when a token names an unknown key
+// but not more often than keyReloadInterval
+const (
+	keyCacheDuration  = time.Minute
+	keyReloadInterval = time.Second * 5
+	rsaKeySize        = 2048
+)
+
+// Another instance publishes a new key once it loads the keys again, clients holding a key set
+// fetched from it right before then only see the key after JWKSMaxAge more
+const keyPublishDelay = JWKSMaxAge + keyCacheDuration
+
+var ErrUnknownKey = errors.New("unknown key")
+
+// Persists the key pairs shared by every instance of the application
+type SigningKeyStore interface {
+	Insert(context.Context, domain.SigningKey) error
+	FindUnexpired(context.Context) ([]domain.SigningKey, error)
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.PrivateKey
+	public    crypto.PublicKey
+	createdAt time.Time
+	retiresAt time.Time
+	expiresAt time.Time
+}
+
+// Newest key of the configured algorithm that is not retired and was published by every instance for JWKSMaxAge.
+// The next key is generated keyPublishDelay before the signing key retires, so that it is published long
+// enough once it takes over. A key that was not published long enough only signs when there is no
+// other, like the first key of the application.
+func (provider *JWTProvider) signingKey() (signingKey, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if err := provider.loadIfStale(); err != nil {
+		return signingKey{}, err
+	}
+
+	now := time.Now()
+	var published, pending *signingKey
+	for i, key := range provider.keys {
+		if key.method.Alg() != provider.algorithm || !now.Before(key.retiresAt) {
+			continue
+		}
+		if now.Before(key.createdAt.Add(keyPublishDelay)) {
+			if pending == nil {
+				pending = &provider.keys[i]
+			}
+		} else if published == nil {
+			published = &provider.keys[i]
+		}
+	}
+
+	if published != nil {
+		if pending == nil && !now.Before(published.retiresAt.Add(-keyPublishDelay)) {
+			if _, err := provider.generate(now); err != nil {
+				return signingKey{}, err
+			}
+		}
+		return *published, nil
+	}
+	if pending != nil {
+		return *pending, nil
+	}
+
+	return provider.generate(now)
+}
+
+// Store a new key pair for every instance, instances rotating at the same time each add a key
+// and every one of them stays valid
+func (provider *JWTProvider) generate(now time.Time) (signingKey, error) {
+	storedKey, err := generateSigningKey(provider.algorithm, now, provider.rotation, provider.gracePeriod)
+	if err != nil {
+		return signingKey{}, err
+	}
+	if err := provider.store.Insert(context.Background(), storedKey); err != nil {
+		return signingKey{}, err
+	}
+
+	key, err := parseSigningKey(storedKey)
+	if err != nil {
+		return signingKey{}, err
+	}
+	provider.keys = append([]signingKey{key}, provider.keys...)
+
+	return key, nil
+}
+
+func (provider *JWTProvider) verificationKey(id string) (signingKey, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if err := provider.loadIfStale(); err != nil {
+		return signingKey{}, err
+	}
+
+	key, found := provider.findKey(id)
+	if !found && time.Since(provider.loadedAt) > keyReloadInterval {
+		if err := provider.load(); err != nil {
+			return signingKey{}, err
+		}
+		key, found = provider.findKey(id)
+	}
+	if !found || !time.Now().Before(key.expiresAt) {
+		return signingKey{}, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (provider *JWTProvider) findKey(id string) (signingKey, bool) {
+	for _, key := range provider.keys {
+		if key.id == id {
+			return key, true
+		}
+	}
+
+	return signingKey{}, false
+}
+
+func (provider *JWTProvider) loadIfStale() error {
+	if time.Since(provider.loadedAt) < keyCacheDuration {
+		return nil
+	}
+
+	return provider.load()
+}
+
+func (provider *JWTProvider) load() error {
+	storedKeys, err := provider.store.FindUnexpired(context.Background())
+	if err != nil {
+		return err
+	}
+
+	keys := []signingKey{}
+	for _, storedKey := range storedKeys {
+		key, err := parseSigningKey(storedKey)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	provider.keys = keys
+	provider.loadedAt = time.Now()
+
+	return nil
+}
+
+func generateSigningKey(algorithm string, now time.Time, rotation time.Duration, gracePeriod time.Duration) (domain.SigningKey, error) {
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		rsaKey, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+		if err != nil {
+			return domain.SigningKey{}, err
+		}
+		private, public = rsaKey, &rsaKey.PublicKey
+	case SigningAlgorithmEdDSA:
+		ed25519Public, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return domain.SigningKey{}, err
+		}
+		private, public = ed25519Private, ed25519Public
+	default:
+		return domain.SigningKey{}, fmt.Errorf("%w: %s", ErrUnknownSigningAlgorithm, algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return domain.SigningKey{}, err
+	}
+
+	// BSON dates only hold milliseconds
+	now = now.UTC().Truncate(time.Millisecond)
+
+	return domain.SigningKey{
+		ID:         id,
+		Algorithm:  algorithm,
+		PrivateKey: privateDER,
+		PublicKey:  publicDER,
+		CreatedAt:  now,
+		RetiresAt:  now.Add(rotation),
+		ExpiresAt:  now.Add(rotation + gracePeriod),
+	}, nil
+}
+
+func parseSigningKey(storedKey domain.SigningKey) (signingKey, error) {
+	key := signingKey{
+		id:        storedKey.ID,
+		createdAt: storedKey.CreatedAt,
+		retiresAt: storedKey.RetiresAt,
+		expiresAt: storedKey.ExpiresAt,
+	}
+
+	switch storedKey.Algorithm {
+	case SigningAlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+	case SigningAlgorithmEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return key, fmt.Errorf("%w: %s", ErrUnknownSigningAlgorithm, storedKey.Algorithm)
+	}
+
+	var err error
+	if key.private, err = x509.ParsePKCS8PrivateKey(storedKey.PrivateKey); err != nil {
+		return key, err
+	}
+	if key.public, err = x509.ParsePKIXPublicKey(storedKey.PublicKey); err != nil {
+		return key, err
+	}
+
+	return key, nil
+}
+
+func (key signingKey) jwk() web.JWK {
+	jwk := web.JWK{
+		Use: "sig",
+		Kid: key.id,
+		Alg: key.method.Alg(),
+	}
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
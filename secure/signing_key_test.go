@@ -0,0 +1,228 @@
+package secure_test
+
+import (
+	"context"
+	"crypto/x509"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/secure"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type memorySigningKeyStore struct {
+	mutex sync.Mutex
+	keys  []domain.SigningKey
+}
+
+func (store *memorySigningKeyStore) Insert(ctx context.Context, signingKey domain.SigningKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.keys = append([]domain.SigningKey{signingKey}, store.keys...)
+	return nil
+}
+
+func (store *memorySigningKeyStore) FindUnexpired(ctx context.Context) ([]domain.SigningKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys := []domain.SigningKey{}
+	for _, key := range store.keys {
+		if time.Now().Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func TestRotatingJWTProvider(t *testing.T) {
+	for _, algorithm := range []string{secure.SigningAlgorithmRS256, secure.SigningAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			store := new(memorySigningKeyStore)
+			provider, err := secure.NewRotatingJWTProvider(time.Minute, "godas", algorithm, store, time.Hour, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := provider.Token(web.JwtClaims{UserID: "user", Roles: []string{domain.RoleAdmin}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Another instance sharing the store verifies the token
+			other, err := secure.NewRotatingJWTProvider(time.Minute, "godas", algorithm, store, time.Hour, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := other.Validate(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserID != "user" || len(claims.Roles) != 1 || claims.Roles[0] != domain.RoleAdmin {
+				t.Fatalf("expected the claims to round trip, got %+v", claims)
+			}
+
+			jwks, err := provider.JWKS()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != store.keys[0].ID || jwks.Keys[0].Alg != algorithm || jwks.Keys[0].Use != "sig" {
+				t.Fatalf("expected the key set to hold the signing key, got %+v", jwks.Keys)
+			}
+			if algorithm == secure.SigningAlgorithmRS256 && (jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].N == "" || jwks.Keys[0].E != "AQAB") {
+				t.Fatalf("expected an RSA key, got %+v", jwks.Keys[0])
+			}
+			if algorithm == secure.SigningAlgorithmEdDSA && (jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].Crv != "Ed25519" || jwks.Keys[0].X == "") {
+				t.Fatalf("expected an Ed25519 key, got %+v", jwks.Keys[0])
+			}
+		})
+	}
+}
+
+func TestRotatingJWTProviderRotation(t *testing.T) {
+	store := new(memorySigningKeyStore)
+	provider, err := secure.NewRotatingJWTProvider(time.Minute, "godas", secure.SigningAlgorithmEdDSA, store, time.Millisecond*10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := provider.Token(web.JwtClaims{UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 20)
+	second, err := provider.Token(web.JwtClaims{UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.keys) != 2 {
+		t.Fatalf("expected a new key after the rotation, got %d keys", len(store.keys))
+	}
+	for _, token := range []string{first, second} {
+		if _, err := provider.Validate(token); err != nil {
+			t.Fatalf("expected tokens of retired keys to be valid during the grace period, got %v", err)
+		}
+	}
+
+	jwks, err := provider.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected the key set to hold both keys, got %d", len(jwks.Keys))
+	}
+}
+
+func TestRotatingJWTProviderRejectsAlgorithmConfusion(t *testing.T) {
+	store := new(memorySigningKeyStore)
+	provider, err := secure.NewRotatingJWTProvider(time.Minute, "godas", secure.SigningAlgorithmRS256, store, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Token(web.JwtClaims{UserID: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := web.JwtClaims{
+		UserID: "user",
+		Roles:  []string{domain.RoleAdmin},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "forged",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	// HS256 with the public key as the secret
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = store.keys[0].ID
+	forgedString, err := forged.SignedString(store.keys[0].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Validate(forgedString); err == nil {
+		t.Fatal("expected an HS256 token to be rejected")
+	}
+
+	// A token without a key id
+	private, err := x509.ParsePKCS8PrivateKey(store.keys[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unnamedString, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Validate(unnamedString); err == nil {
+		t.Fatal("expected a token without a key id to be rejected")
+	}
+
+	if _, err := secure.NewRotatingJWTProvider(time.Minute, "godas", secure.SigningAlgorithmHS256, store, time.Hour, time.Hour); err == nil {
+		t.Fatal("expected a shared secret algorithm to be refused")
+	}
+}
+
+func TestRotatingJWTProviderPublishesKeysBeforeSigning(t *testing.T) {
+	store := new(memorySigningKeyStore)
+	newProvider := func() *secure.JWTProvider {
+		provider, err := secure.NewRotatingJWTProvider(time.Minute, "godas", secure.SigningAlgorithmEdDSA, store, time.Hour, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return provider
+	}
+	kid := func(provider *secure.JWTProvider) string {
+		token, err := provider.Token(web.JwtClaims{UserID: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &web.JwtClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.Header["kid"].(string)
+	}
+
+	// The first key signs right away, there is no other
+	first := kid(newProvider())
+
+	// Shortly before it retires the next key is published, the current key keeps signing
+	now := time.Now()
+	store.keys[0].CreatedAt, store.keys[0].RetiresAt = now.Add(-time.Hour+time.Minute), now.Add(time.Minute)
+	provider := newProvider()
+	if signer := kid(provider); signer != first {
+		t.Fatalf("expected the published key to sign, got %s", signer)
+	}
+	if len(store.keys) != 2 {
+		t.Fatalf("expected the next key to be generated, got %d keys", len(store.keys))
+	}
+	next := store.keys[0].ID
+	jwks, err := provider.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected the next key to be published, got %d keys", len(jwks.Keys))
+	}
+	if signer := kid(provider); signer != first || len(store.keys) != 2 {
+		t.Fatalf("expected the published key to keep signing without another key, got %s and %d keys", signer, len(store.keys))
+	}
+
+	// Other instances may only publish the next key up to a minute later, it does not sign yet
+	store.keys[0].CreatedAt = now.Add(-secure.JWKSMaxAge)
+	if signer := kid(newProvider()); signer != first {
+		t.Fatalf("expected the published key to keep signing, got %s", signer)
+	}
+
+	// Once the next key was published long enough by every instance it takes over
+	store.keys[0].CreatedAt = now.Add(-secure.JWKSMaxAge - 2*time.Minute)
+	store.keys[1].RetiresAt = now
+	if signer := kid(newProvider()); signer != next || len(store.keys) != 2 {
+		t.Fatalf("expected the next key to sign, got %s and %d keys", signer, len(store.keys))
+	}
+}
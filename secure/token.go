@@ -0,0 +1,51 @@
+package secure
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const DefaultRefreshTokenExpiration = time.Hour * 24 * 30
+
+const opaqueTokenSize = 32
+
+// Generate a random token that is only meaningful to the server, along with the hash to store
+func NewOpaqueToken() (token string, hash string, err error) {
+	buffer := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(buffer)
+
+	return token, HashOpaqueToken(token), nil
+}
+
+// Opaque tokens are random, so a fast hash is enough to store them
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// API keys are opaque tokens with a prefix that tells them apart from access tokens
+const APIKeyPrefix = "godas_"
+
+func NewAPIKey() (key string, hash string, err error) {
+	token, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	key = APIKeyPrefix + token
+
+	return key, HashOpaqueToken(key), nil
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
@@ -0,0 +1,89 @@
+package secure_test
+
+import (
+	"encoding/base32"
+	"godas/secure"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors of RFC 6238 appendix B for SHA1, the last six digits of the eight digit codes
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, vector := range vectors {
+		code, err := secure.TOTPCode(secret, time.Unix(vector.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != vector.code {
+			t.Fatalf("expected %s at %d, got %s", vector.code, vector.unix, code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := secure.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Unix(1111111111, 0)
+
+	code, err := secure.TOTPCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, match := secure.VerifyTOTP(secret, code, at)
+	if !match || step != at.Unix()/30 {
+		t.Fatalf("expected the code to match at step %d, got %d (%v)", at.Unix()/30, step, match)
+	}
+	if _, match := secure.VerifyTOTP(secret, code, at.Add(30*time.Second)); !match {
+		t.Fatal("expected the code of the previous period to match")
+	}
+	if _, match := secure.VerifyTOTP(secret, code, at.Add(90*time.Second)); match {
+		t.Fatal("expected an old code not to match")
+	}
+	if _, match := secure.VerifyTOTP(secret, "", at); match {
+		t.Fatal("expected an empty code not to match")
+	}
+
+	uri := secure.TOTPURI("godas", "a@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/godas:a@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := secure.NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("expected 10 codes, got %d", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if seen[code] {
+			t.Fatalf("expected unique codes, got %s twice", code)
+		}
+		seen[code] = true
+		if secure.HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", ""))) != hashes[i] {
+			t.Fatalf("expected %s to match its hash regardless of case and dash", code)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package secure
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// Length of emailed codes, longer codes are harder to guess but harder to type
+const (
+	DefaultVerificationCodeLength = 6
+	MinVerificationCodeLength     = 6
+	MaxVerificationCodeLength     = 32
+)
+
+const verificationCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Generate a code to send by email, every character is drawn uniformly from uppercase letters and digits
+func NewVerificationCode(length int) (string, error) {
+	alphabetSize := big.NewInt(int64(len(verificationCodeAlphabet)))
+
+	code := make([]byte, length)
+	for i := range code {
+		index, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code[i] = verificationCodeAlphabet[index.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// Codes are compared without their case, the hash is stored instead of the code
+func HashVerificationCode(code string) string {
+	return HashOpaqueToken(strings.ToUpper(strings.TrimSpace(code)))
+}
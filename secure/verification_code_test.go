@@ -0,0 +1,41 @@
+package secure_test
+
+import (
+	"godas/secure"
+	"strings"
+	"testing"
+)
+
+func TestNewVerificationCode(t *testing.T) {
+	seen := map[string]bool{}
+	for _, length := range []int{secure.MinVerificationCodeLength, secure.MaxVerificationCodeLength} {
+		for i := 0; i < 100; i++ {
+			code, err := secure.NewVerificationCode(length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(code) != length {
+				t.Fatalf("expected a code of %d characters, got %q", length, code)
+			}
+			if strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+				t.Fatalf("expected uppercase letters and digits, got %q", code)
+			}
+			seen[code] = true
+		}
+	}
+	if len(seen) < 190 {
+		t.Fatalf("expected random codes, got %d distinct codes out of 200", len(seen))
+	}
+}
+
+func TestHashVerificationCode(t *testing.T) {
+	if secure.HashVerificationCode("ABC123") != secure.HashVerificationCode(" abc123") {
+		t.Fatal("expected codes to be compared without their case")
+	}
+	if secure.HashVerificationCode("ABC123") == secure.HashVerificationCode("ABC124") {
+		t.Fatal("expected different codes to have different hashes")
+	}
+	if secure.HashVerificationCode("ABC123") == "ABC123" {
+		t.Fatal("expected the code not to be stored as is")
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/secure"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type APIKeyService interface {
+	Create(owner string, request web.APIKeyCreateRequest) (web.APIKeyCreateResponse, error)
+	FindAllFromOwner(owner string) ([]web.APIKeyResponse, error)
+	DeleteFromOwner(id string, owner string) error
+}
+
+const DefaultAPIKeyExpirationDays = 90
+
+// Length of the start of a key that is kept to recognize it, the prefix and a few random characters
+const apiKeyDisplayLength = len(secure.APIKeyPrefix) + 6
+
+type APIKeyServiceImpl struct {
+	apiKeyRepository repository.APIKeyRepository
+	validate         *validator.Validate
+}
+
+func NewAPIKeyService(apiKeyRepository repository.APIKeyRepository, validate *validator.Validate) APIKeyService {
+	apiKeyService := new(APIKeyServiceImpl)
+	apiKeyService.apiKeyRepository = apiKeyRepository
+	apiKeyService.validate = validate
+
+	return apiKeyService
+}
+
+func (service *APIKeyServiceImpl) Create(owner string, request web.APIKeyCreateRequest) (web.APIKeyCreateResponse, error) {
+	response := web.APIKeyCreateResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	scopes := []domain.Permission{}
+	for _, scope := range request.Scopes {
+		if !knownPermission(scope) {
+			return response, ErrBadRequest
+		}
+		if !containsPermission(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiresInDays := request.ExpiresInDays
+	if expiresInDays == 0 {
+		expiresInDays = DefaultAPIKeyExpirationDays
+	}
+
+	key, hash, err := secure.NewAPIKey()
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	apiKey, err := service.apiKeyRepository.Insert(context.Background(), domain.APIKey{
+		UserID:    owner,
+		Name:      request.Name,
+		Hash:      hash,
+		Prefix:    key[:apiKeyDisplayLength],
+		Scopes:    scopes,
+		ExpiresAt: now.AddDate(0, 0, expiresInDays),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	response = web.APIKeyCreateResponse{
+		APIKeyResponse: newAPIKeyResponse(apiKey),
+		Key:            key,
+	}
+
+	return response, nil
+}
+
+func (service *APIKeyServiceImpl) FindAllFromOwner(owner string) ([]web.APIKeyResponse, error) {
+	apiKeys, err := service.apiKeyRepository.FindByUser(context.Background(), owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired keys are only removed periodically by the database
+	response := []web.APIKeyResponse{}
+	for _, apiKey := range apiKeys {
+		if time.Now().Before(apiKey.ExpiresAt) {
+			response = append(response, newAPIKeyResponse(apiKey))
+		}
+	}
+
+	return response, nil
+}
+
+func (service *APIKeyServiceImpl) DeleteFromOwner(id string, owner string) error {
+	if err := service.apiKeyRepository.DeleteFromOwner(context.Background(), id, owner); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func newAPIKeyResponse(apiKey domain.APIKey) web.APIKeyResponse {
+	response := web.APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if response.Scopes == nil {
+		response.Scopes = []domain.Permission{}
+	}
+	if !apiKey.LastUsedAt.IsZero() {
+		lastUsedAt := apiKey.LastUsedAt
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
@@ -3,56 +3,327 @@ package service
 import (
 	"context"
 	"errors"
+	"godas/model/domain"
 	"godas/model/web"
 	"godas/repository"
 	"godas/secure"
+	"os"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 type AuthService interface {
-	Signin(web.AuthRequest) (string, error)
+	Signin(web.AuthRequest) (web.TokenResponse, error)
+	SigninMFA(web.MFASigninRequest) (web.TokenResponse, error)
+	Refresh(web.RefreshRequest) (web.TokenResponse, error)
 	Validate(string) (web.AuthResponse, error)
+	Signout(web.AuthResponse, web.SignoutRequest) error
+	RevokeSessions(string) error
+	ChangePassword(web.AuthResponse, web.PasswordChangeRequest) error
+	ForgotPassword(web.PasswordForgotRequest) error
+	ResetPassword(web.PasswordResetRequest) error
+	JWKS() (web.JWKSResponse, error)
 }
 
+// How often the last use of an API key is recorded at most
+const apiKeyTouchInterval = time.Minute
+
 type AuthServiceImpl struct {
-	userRepository repository.UserRepository
-	jwtProvider    *secure.JWTProvider
+	userRepository           repository.UserRepository
+	refreshTokenRepository   repository.RefreshTokenRepository
+	revokedTokenRepository   repository.RevokedTokenRepository
+	roleRepository           repository.RoleRepository
+	apiKeyRepository         repository.APIKeyRepository
+	mfaChallengeRepository   repository.MFAChallengeRepository
+	loginAttemptRepository   repository.LoginAttemptRepository
+	emailVerificationService EmailVerificationService
+	validate                 *validator.Validate
+	jwtProvider              *secure.JWTProvider
+	passwordHasher           *secure.PasswordHasher
+	refreshTokenExpiration   time.Duration
+	now                      func() time.Time
 }
 
-func NewAuthService(userRepository repository.UserRepository, jwtProvider *secure.JWTProvider) AuthService {
+func NewAuthService(userRepository repository.UserRepository, refreshTokenRepository repository.RefreshTokenRepository, revokedTokenRepository repository.RevokedTokenRepository, roleRepository repository.RoleRepository, apiKeyRepository repository.APIKeyRepository, mfaChallengeRepository repository.MFAChallengeRepository, loginAttemptRepository repository.LoginAttemptRepository, emailVerificationService EmailVerificationService, validate *validator.Validate, jwtProvider *secure.JWTProvider, passwordHasher *secure.PasswordHasher, refreshTokenExpiration time.Duration) AuthService {
 	authService := new(AuthServiceImpl)
 	authService.userRepository = userRepository
+	authService.refreshTokenRepository = refreshTokenRepository
+	authService.revokedTokenRepository = revokedTokenRepository
+	authService.roleRepository = roleRepository
+	authService.apiKeyRepository = apiKeyRepository
+	authService.mfaChallengeRepository = mfaChallengeRepository
+	authService.loginAttemptRepository = loginAttemptRepository
+	authService.emailVerificationService = emailVerificationService
+	authService.validate = validate
 	authService.jwtProvider = jwtProvider
+	authService.passwordHasher = passwordHasher
+	authService.refreshTokenExpiration = refreshTokenExpiration
+	authService.now = time.Now
 
 	return authService
 }
 
-func (service *AuthServiceImpl) Signin(request web.AuthRequest) (string, error) {
+// Failed sign ins are counted for the account and for the address of the request,
+// both are refused for a while once they failed too often
+func (service *AuthServiceImpl) Signin(request web.AuthRequest) (web.TokenResponse, error) {
+	response := web.TokenResponse{}
+
+	now := service.now()
+	keys := loginKeys(request.Email, request.IP)
+	if err := checkLoginAttempts(context.Background(), service.loginAttemptRepository, keys, now); err != nil {
+		return response, err
+	}
+
 	user, err := service.userRepository.FindByEmail(context.Background(), request.Email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
-			return "", ErrNotFound
+			if err := failLoginAttempts(context.Background(), service.loginAttemptRepository, keys, now); err != nil {
+				return response, err
+			}
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	match, rehash := service.passwordHasher.Verify(user.Password, request.Password)
+	if !match {
+		if err := failLoginAttempts(context.Background(), service.loginAttemptRepository, keys, now); err != nil {
+			return response, err
+		}
+		return response, ErrUnauthorized
+	}
+	if !user.Verified {
+		return response, ErrUnauthorized
+	}
+
+	// Upgrade plaintext and outdated hashes now that the password is known. A password too long to be
+	// hashed in full has to be replaced with a reset, its hash would accept any password sharing its beginning.
+	if rehash {
+		if !validPasswordLength(request.Password) {
+			return response, ErrUnauthorized
+		}
+		hash, err := service.passwordHasher.Hash(request.Password)
+		if err != nil {
+			return response, err
+		}
+		if err := service.userRepository.SetPassword(context.Background(), user.ID, hash); err != nil {
+			return response, err
+		}
+	}
+
+	if user.TOTPEnabled {
+		return service.challenge(user)
+	}
+
+	// Only the failures of the account are forgotten, others may share the address
+	if err := service.loginAttemptRepository.Delete(context.Background(), accountLoginKey(request.Email).id); err != nil {
+		return response, err
+	}
+
+	return service.issue(user, "")
+}
+
+// Start a sign in that is completed by SigninMFA, the token only identifies the challenge
+func (service *AuthServiceImpl) challenge(user domain.User) (web.TokenResponse, error) {
+	response := web.TokenResponse{}
+
+	token, hash, err := secure.NewOpaqueToken()
+	if err != nil {
+		return response, err
+	}
+
+	if err := service.mfaChallengeRepository.Insert(context.Background(), domain.MFAChallenge{
+		ID:        hash,
+		UserID:    user.ID,
+		Attempts:  0,
+		ExpiresAt: service.now().Add(mfaChallengeExpiration),
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.TokenResponse{
+		MFARequired: true,
+		MFAToken:    token,
+	}
+
+	return response, nil
+}
+
+// Complete a sign in started by Signin with a TOTP code or a recovery code.
+// Each challenge only allows a few attempts and is removed once it succeeds.
+func (service *AuthServiceImpl) SigninMFA(request web.MFASigninRequest) (web.TokenResponse, error) {
+	response := web.TokenResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	challenge, err := service.mfaChallengeRepository.Attempt(context.Background(), secure.HashOpaqueToken(request.MFAToken), mfaChallengeMaxAttempts)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		}
+		return response, err
+	}
+	// Expired challenges are only removed periodically by the database
+	now := service.now()
+	if !now.Before(challenge.ExpiresAt) {
+		return response, ErrUnauthorized
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), challenge.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		}
+		return response, err
+	}
+	if !user.Verified || !user.TOTPEnabled {
+		return response, ErrUnauthorized
+	}
+
+	// Wrong codes count against the account too, every new challenge would allow more guesses otherwise
+	keys := []loginKey{accountLoginKey(user.Email)}
+	if err := checkLoginAttempts(context.Background(), service.loginAttemptRepository, keys, now); err != nil {
+		return response, err
+	}
+	if err := useSecondFactor(context.Background(), service.userRepository, user, request.Code, now); err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			if err := failLoginAttempts(context.Background(), service.loginAttemptRepository, keys, now); err != nil {
+				return response, err
+			}
+		}
+		return response, err
+	}
+
+	// Only one of concurrent attempts with valid codes gets tokens
+	if err := service.mfaChallengeRepository.Delete(context.Background(), challenge.ID); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		}
+		return response, err
+	}
+	if err := service.loginAttemptRepository.Delete(context.Background(), keys[0].id); err != nil {
+		return response, err
+	}
+
+	return service.issue(user, "")
+}
+
+// Exchange a refresh token for a new pair of tokens, every refresh token can only be used once.
+// Using a token twice means it leaked, so every token of its family is revoked.
+func (service *AuthServiceImpl) Refresh(request web.RefreshRequest) (web.TokenResponse, error) {
+	response := web.TokenResponse{}
+
+	if request.RefreshToken == "" {
+		return response, ErrBadRequest
+	}
+
+	refreshToken, err := service.refreshTokenRepository.Use(context.Background(), secure.HashOpaqueToken(request.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		} else if errors.Is(err, repository.ErrUsedData) {
+			if err := service.refreshTokenRepository.DeleteByFamily(context.Background(), refreshToken.Family); err != nil {
+				return response, err
+			}
+			return response, ErrUnauthorized
 		}
-		return "", err
+		return response, err
 	}
 
-	if user.Password != request.Password || !user.Verified {
-		return "", ErrUnauthorized
+	// Expired tokens are only removed periodically by the database
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return response, ErrUnauthorized
 	}
 
-	return service.jwtProvider.Token(web.JwtClaims{
+	user, err := service.userRepository.FindById(context.Background(), refreshToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		}
+		return response, err
+	}
+	if !user.Verified {
+		return response, ErrUnauthorized
+	}
+
+	return service.issue(user, refreshToken.Family)
+}
+
+// Issue an access token and a refresh token, an empty family starts a new one
+func (service *AuthServiceImpl) issue(user domain.User, family string) (web.TokenResponse, error) {
+	response := web.TokenResponse{}
+
+	accessToken, err := service.jwtProvider.Token(web.JwtClaims{
 		UserID: user.ID,
-		Role:   user.Role,
+		Roles:  user.RoleNames(),
 	})
+	if err != nil {
+		return response, err
+	}
+
+	token, hash, err := secure.NewOpaqueToken()
+	if err != nil {
+		return response, err
+	}
+	if family == "" {
+		family = hash
+	}
+
+	now := time.Now()
+	if err := service.refreshTokenRepository.Insert(context.Background(), domain.RefreshToken{
+		ID:        hash,
+		Family:    family,
+		UserID:    user.ID,
+		Used:      false,
+		ExpiresAt: now.Add(service.refreshTokenExpiration),
+		CreatedAt: now,
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(service.jwtProvider.Expiration().Seconds()),
+	}
+
+	return response, nil
 }
 
 func (service *AuthServiceImpl) Validate(tokenString string) (web.AuthResponse, error) {
 	response := web.AuthResponse{}
 
+	if secure.IsAPIKey(tokenString) {
+		return service.validateAPIKey(tokenString)
+	}
+
 	claims, err := service.jwtProvider.Validate(tokenString)
 	if err != nil {
+		// Expired, forged and malformed tokens are the caller's, failing to read the keys is not
+		var validationError *jwt.ValidationError
+		if errors.As(err, &validationError) {
+			return response, ErrUnauthorized
+		}
 		return response, err
 	}
 
+	// Tokens without an id were issued before tokens could be revoked
+	if claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return response, ErrUnauthorized
+	}
+	revoked, err := service.revokedTokenRepository.Exists(context.Background(), claims.ID)
+	if err != nil {
+		return response, err
+	}
+	if revoked {
+		return response, ErrUnauthorized
+	}
+
 	user, err := service.userRepository.FindById(context.Background(), claims.UserID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
@@ -64,10 +335,256 @@ func (service *AuthServiceImpl) Validate(tokenString string) (web.AuthResponse,
 		return response, ErrUnauthorized
 	}
 
+	if !user.TokensRevokedAt.IsZero() && issuedBefore(claims, user.TokensRevokedAt) {
+		return response, ErrUnauthorized
+	}
+
+	// Roles are read from the user, the ones in the token may be outdated
+	permissions, err := rolePermissions(context.Background(), service.roleRepository, user.RoleNames())
+	if err != nil {
+		return response, err
+	}
+
 	response = web.AuthResponse{
-		ID:   claims.UserID,
-		Role: claims.Role,
+		ID:             claims.UserID,
+		Roles:          user.RoleNames(),
+		Permissions:    permissions,
+		TokenID:        claims.ID,
+		TokenExpiresAt: claims.ExpiresAt.Time,
 	}
 
 	return response, nil
 }
+
+// An API key grants the permissions of its scopes that the user still holds through its roles
+func (service *AuthServiceImpl) validateAPIKey(key string) (web.AuthResponse, error) {
+	response := web.AuthResponse{}
+
+	apiKey, err := service.apiKeyRepository.FindByHash(context.Background(), secure.HashOpaqueToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrUnauthorized
+		}
+		return response, err
+	}
+	// Expired keys are only removed periodically by the database
+	now := time.Now()
+	if !now.Before(apiKey.ExpiresAt) {
+		return response, ErrUnauthorized
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+	if !user.Verified {
+		return response, ErrUnauthorized
+	}
+
+	rolePermissions, err := rolePermissions(context.Background(), service.roleRepository, user.RoleNames())
+	if err != nil {
+		return response, err
+	}
+	permissions := []domain.Permission{}
+	for _, scope := range apiKey.Scopes {
+		if containsPermission(rolePermissions, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	// The last use is only recorded once in a while, failing to record it does not fail the request
+	if now.Sub(apiKey.LastUsedAt) > apiKeyTouchInterval {
+		service.apiKeyRepository.Touch(context.Background(), apiKey.ID, now)
+	}
+
+	response = web.AuthResponse{
+		ID:             user.ID,
+		Roles:          user.RoleNames(),
+		Permissions:    permissions,
+		TokenExpiresAt: apiKey.ExpiresAt,
+		APIKeyID:       apiKey.ID,
+	}
+
+	return response, nil
+}
+
+// Revoke the access token of the request, and the session of the refresh token when one is given.
+// API keys are revoked by deleting them, not by signing out.
+func (service *AuthServiceImpl) Signout(authResponse web.AuthResponse, request web.SignoutRequest) error {
+	if authResponse.APIKeyID != "" {
+		return ErrBadRequest
+	}
+
+	if err := service.revokedTokenRepository.Insert(context.Background(), domain.RevokedToken{
+		ID:        authResponse.TokenID,
+		UserID:    authResponse.ID,
+		ExpiresAt: authResponse.TokenExpiresAt,
+	}); err != nil {
+		return err
+	}
+
+	if request.RefreshToken == "" {
+		return nil
+	}
+
+	refreshToken, err := service.refreshTokenRepository.FindById(context.Background(), secure.HashOpaqueToken(request.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil
+		}
+		return err
+	}
+	if refreshToken.UserID != authResponse.ID {
+		return ErrUnauthorized
+	}
+
+	return service.refreshTokenRepository.DeleteByFamily(context.Background(), refreshToken.Family)
+}
+
+// Revocations are stored in milliseconds, tokens issued before the issue time was carried in milliseconds
+// only hold seconds so those issued in the same second as the revocation are revoked too
+func issuedBefore(claims web.JwtClaims, at time.Time) bool {
+	if claims.IssuedAtMilli == 0 {
+		return claims.IssuedAt.Unix() <= at.Unix()
+	}
+	return claims.IssuedAtMilli <= at.UnixMilli()
+}
+
+// End every session of a user, access tokens issued until now are rejected and refresh tokens are deleted
+func (service *AuthServiceImpl) RevokeSessions(id string) error {
+	if err := service.userRepository.RevokeTokens(context.Background(), id, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return service.refreshTokenRepository.DeleteByUser(context.Background(), id)
+}
+
+// Public keys that verify access tokens, for services that check tokens on their own
+func (service *AuthServiceImpl) JWKS() (web.JWKSResponse, error) {
+	return service.jwtProvider.JWKS()
+}
+
+// Send a password reset code. The outcome is the same whether the account exists or a code was
+// sent recently, so that the response does not tell which addresses have an account.
+func (service *AuthServiceImpl) ForgotPassword(request web.PasswordForgotRequest) error {
+	if err := service.validate.Struct(request); err != nil {
+		return ErrBadRequest
+	}
+
+	if _, err := service.userRepository.FindByEmail(context.Background(), request.Email); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil
+		}
+		return err
+	}
+
+	email := domain.EmailVerificationSend{
+		FromName:  os.Getenv("APP_NAME"),
+		FromEmail: os.Getenv("EMAIL"),
+		ToEmail:   request.Email,
+		Purpose:   domain.EmailVerificationPurposePasswordReset,
+		Title:     "Password Reset",
+	}
+
+	_, err := service.emailVerificationService.Recreate(email)
+	if errors.Is(err, ErrNotFound) {
+		_, err = service.emailVerificationService.Create(email)
+	}
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrDuplicate) {
+		return nil
+	}
+
+	return err
+}
+
+// Set a new password with a code sent by ForgotPassword, every session of the user is ended.
+// Receiving the code proves the address belongs to the user, so the user is verified too.
+func (service *AuthServiceImpl) ResetPassword(request web.PasswordResetRequest) error {
+	if err := service.validate.Struct(request); err != nil || !validPasswordLength(request.Password) {
+		return ErrBadRequest
+	}
+
+	if _, err := service.emailVerificationService.Verification(domain.EmailVerificationPurposePasswordReset, "", web.EmailVerificationCreateRequest{
+		Email: request.Email,
+		Code:  request.Code,
+	}); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrUnauthorized
+		}
+		return err
+	}
+
+	user, err := service.userRepository.FindByEmail(context.Background(), request.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrUnauthorized
+		}
+		return err
+	}
+
+	hash, err := service.passwordHasher.Hash(request.Password)
+	if err != nil {
+		return err
+	}
+	if err := service.userRepository.SetPassword(context.Background(), user.ID, hash); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrUnauthorized
+		}
+		return err
+	}
+	// Receiving the code proves the address just like the signup code does
+	if !user.Verified {
+		if err := service.userRepository.SetVerified(context.Background(), user.ID); err != nil {
+			return err
+		}
+	}
+
+	// Failures guessing the old password no longer keep the owner out
+	if err := service.loginAttemptRepository.Delete(context.Background(), accountLoginKey(user.Email).id); err != nil {
+		return err
+	}
+
+	return service.RevokeSessions(user.ID)
+}
+
+// Validation tags count characters while the limit is in bytes
+func validPasswordLength(password string) bool {
+	return len(password) <= secure.MaxPasswordLength
+}
+
+// Replace the password of the signed in user, every session of the user is ended afterwards
+func (service *AuthServiceImpl) ChangePassword(authResponse web.AuthResponse, request web.PasswordChangeRequest) error {
+	if err := service.validate.Struct(request); err != nil || !validPasswordLength(request.Password) {
+		return ErrBadRequest
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), authResponse.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if match, _ := service.passwordHasher.Verify(user.Password, request.CurrentPassword); !match {
+		return ErrUnauthorized
+	}
+
+	hash, err := service.passwordHasher.Hash(request.Password)
+	if err != nil {
+		return err
+	}
+	if err := service.userRepository.SetPassword(context.Background(), user.ID, hash); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return service.RevokeSessions(user.ID)
+}
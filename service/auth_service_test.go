@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/secure"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type memoryRevokedTokenRepository struct {
+	repository.RevokedTokenRepository
+}
+
+func (memory *memoryRevokedTokenRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
+// Accept every code, the codes themselves are tested with the email verification service
+type acceptingEmailVerificationService struct {
+	EmailVerificationService
+}
+
+func (verification *acceptingEmailVerificationService) Verification(purpose string, userID string, request web.EmailVerificationCreateRequest) (domain.EmailVerification, error) {
+	return domain.EmailVerification{Email: request.Email, Purpose: purpose}, nil
+}
+
+func TestResetPassword(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := passwordHasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Name: "A", Password: hash},
+	}}
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{{ID: domain.RoleClient, Permissions: []domain.Permission{}}}}
+	loginAttemptRepository := newMemoryLoginAttemptRepository()
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, &memoryRevokedTokenRepository{}, roleRepository, nil, nil, loginAttemptRepository, &acceptingEmailVerificationService{}, validator.New(), secure.NewJWTProvider(time.Minute, "test", "key"), passwordHasher, time.Hour)
+
+	// The account is locked by someone guessing its password
+	accountKey := accountLoginKey("a@example.com").id
+	loginAttemptRepository.loginAttempts[accountKey] = domain.LoginAttempt{ID: accountKey, Failures: loginAccountFailures, LockedUntil: time.Now().Add(time.Hour)}
+
+	// bcrypt would ignore the last bytes of a longer password, the limit is in bytes and not characters
+	if err := authService.ResetPassword(web.PasswordResetRequest{Email: "a@example.com", Code: "123456", Password: strings.Repeat("é", 37)}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected a password of 74 bytes to be refused, got %v", err)
+	}
+	if err := authService.ChangePassword(web.AuthResponse{ID: "1"}, web.PasswordChangeRequest{CurrentPassword: "password", Password: strings.Repeat("a", 73)}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected a password of 73 bytes to be refused, got %v", err)
+	}
+
+	if err := authService.ResetPassword(web.PasswordResetRequest{Email: "a@example.com", Code: "123456", Password: "new password"}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := userRepository.users["1"]
+	if !user.Verified || user.Name != "A" {
+		t.Fatalf("expected only the password and verification to change, got %+v", user)
+	}
+	if _, found := loginAttemptRepository.loginAttempts[accountKey]; found {
+		t.Fatal("expected the failures of the account to be forgotten")
+	}
+	if _, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "new password"}); err != nil {
+		t.Fatalf("expected the new password to be accepted, got %v", err)
+	}
+	if _, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected the old password to be refused, got %v", err)
+	}
+}
+
+func TestSigninLongLegacyPassword(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Passwords stored in plaintext were never limited in length
+	password := strings.Repeat("a", 80)
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Password: password, Verified: true, Roles: []string{domain.RoleClient}},
+	}}
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{{ID: domain.RoleClient, Permissions: []domain.Permission{}}}}
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, &memoryRevokedTokenRepository{}, roleRepository, nil, nil, newMemoryLoginAttemptRepository(), nil, validator.New(), secure.NewJWTProvider(time.Minute, "test", "key"), passwordHasher, time.Hour)
+
+	if _, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: password}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a password of 80 bytes to require a reset, got %v", err)
+	}
+	if userRepository.users["1"].Password != password {
+		t.Fatal("expected the password not to be hashed, bcrypt would only keep its first 72 bytes")
+	}
+	if _, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: strings.Repeat("a", 72) + "b"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected another password sharing the first 72 bytes to be refused, got %v", err)
+	}
+}
+
+func TestSigninAfterRevocation(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := passwordHasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Password: hash, Verified: true, Roles: []string{domain.RoleClient}},
+	}}
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{{ID: domain.RoleClient, Permissions: []domain.Permission{}}}}
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, &memoryRevokedTokenRepository{}, roleRepository, nil, nil, newMemoryLoginAttemptRepository(), nil, validator.New(), secure.NewJWTProvider(time.Minute, "test", "key"), passwordHasher, time.Hour)
+
+	revoked, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.RevokeSessions("1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing in right after the revocation usually happens in the same second
+	time.Sleep(time.Millisecond)
+	token, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.Validate(token.AccessToken); err != nil {
+		t.Fatalf("expected a token issued after the revocation to be accepted, got %v", err)
+	}
+	if _, err := authService.Validate(revoked.AccessToken); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a token issued before the revocation to be refused, got %v", err)
+	}
+}
+
+func TestValidateExpiredToken(t *testing.T) {
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Verified: true, Roles: []string{domain.RoleClient}},
+	}}
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{{ID: domain.RoleClient, Permissions: []domain.Permission{}}}}
+	newAuthService := func(jwtProvider *secure.JWTProvider) AuthService {
+		return NewAuthService(userRepository, &memoryRefreshTokenRepository{}, &memoryRevokedTokenRepository{}, roleRepository, nil, nil, newMemoryLoginAttemptRepository(), nil, validator.New(), jwtProvider, nil, time.Hour)
+	}
+
+	// The client is expected to refresh its session, which it only does when told it is unauthorized
+	expired, err := secure.NewJWTProvider(-time.Minute, "test", "key").Token(web.JwtClaims{UserID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newAuthService(secure.NewJWTProvider(time.Minute, "test", "key")).Validate(expired); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected an expired token to be refused as unauthorized, got %v", err)
+	}
+	if _, err := newAuthService(secure.NewJWTProvider(time.Minute, "test", "other key")).Validate(expired); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a token signed with another key to be refused as unauthorized, got %v", err)
+	}
+	if _, err := newAuthService(secure.NewJWTProvider(time.Minute, "test", "key")).Validate("not a token"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a malformed token to be refused as unauthorized, got %v", err)
+	}
+}
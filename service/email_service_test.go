@@ -1 +1,327 @@
 package service_test
+
+import (
+	"context"
+	"errors"
+	"godas/mailer"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/secure"
+	"godas/service"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type memoryEmailVerificationRepository struct {
+	emailVerifications map[string]domain.EmailVerification
+}
+
+func (memory *memoryEmailVerificationRepository) Insert(ctx context.Context, emailVerification domain.EmailVerification) error {
+	key := emailVerification.Email + "/" + emailVerification.Purpose
+	if _, found := memory.emailVerifications[key]; found {
+		return repository.ErrDuplicateData
+	}
+	memory.emailVerifications[key] = emailVerification
+	return nil
+}
+
+func (memory *memoryEmailVerificationRepository) FindByEmail(ctx context.Context, email string, purpose string) (domain.EmailVerification, error) {
+	emailVerification, found := memory.emailVerifications[email+"/"+purpose]
+	if !found {
+		return emailVerification, repository.ErrNoData
+	}
+	return emailVerification, nil
+}
+
+func (memory *memoryEmailVerificationRepository) Update(ctx context.Context, emailVerification domain.EmailVerification) (domain.EmailVerification, error) {
+	key := emailVerification.Email + "/" + emailVerification.Purpose
+	if _, found := memory.emailVerifications[key]; !found {
+		return emailVerification, repository.ErrNoData
+	}
+	memory.emailVerifications[key] = emailVerification
+	return emailVerification, nil
+}
+
+func (memory *memoryEmailVerificationRepository) Renew(ctx context.Context, emailVerification domain.EmailVerification, at int64) error {
+	key := emailVerification.Email + "/" + emailVerification.Purpose
+	stored, found := memory.emailVerifications[key]
+	if !found {
+		return repository.ErrNoData
+	}
+	if stored.Cooldown > at {
+		return repository.ErrLockedData
+	}
+	memory.emailVerifications[key] = emailVerification
+	return nil
+}
+
+func (memory *memoryEmailVerificationRepository) Attempt(ctx context.Context, email string, purpose string, userID string) (domain.EmailVerification, error) {
+	emailVerification, found := memory.emailVerifications[email+"/"+purpose]
+	if !found || (userID != "" && emailVerification.UserID != userID) {
+		return emailVerification, repository.ErrNoData
+	}
+	emailVerification.Attempts++
+	memory.emailVerifications[email+"/"+purpose] = emailVerification
+	return emailVerification, nil
+}
+
+func (memory *memoryEmailVerificationRepository) Delete(ctx context.Context, email string, purpose string) error {
+	if _, found := memory.emailVerifications[email+"/"+purpose]; !found {
+		return repository.ErrNoData
+	}
+	delete(memory.emailVerifications, email+"/"+purpose)
+	return nil
+}
+
+func TestVerificationAttempts(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{}}
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, mailer.NewMemoryMailer(), validator.New(), secure.DefaultVerificationCodeLength, "")
+
+	newVerification := func() {
+		emailVerificationRepository.emailVerifications["a@example.com/"+domain.EmailVerificationPurposeSignup] = domain.EmailVerification{
+			Email:      "a@example.com",
+			Purpose:    domain.EmailVerificationPurposeSignup,
+			CodeHash:   secure.HashVerificationCode("ABC123"),
+			Expiration: time.Now().Add(time.Minute).Unix(),
+		}
+	}
+	guess := func(code string) error {
+		_, err := emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", web.EmailVerificationCreateRequest{
+			Email: "a@example.com",
+			Code:  code,
+		})
+		return err
+	}
+
+	newVerification()
+	if err := guess("WRONG1"); !errors.Is(err, service.ErrUnauthorized) {
+		t.Fatalf("expected a wrong code to be refused, got %v", err)
+	}
+	if err := guess("abc123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guess("ABC123"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected the code to be used once, got %v", err)
+	}
+
+	// Too many wrong guesses throw the code away, even the right one is refused afterwards
+	newVerification()
+	for i := 0; i < 5; i++ {
+		if err := guess("WRONG1"); !errors.Is(err, service.ErrUnauthorized) {
+			t.Fatalf("expected a wrong code to be refused, got %v", err)
+		}
+	}
+	if err := guess("ABC123"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("expected the code to be thrown away, got %v", err)
+	}
+}
+
+func TestVerificationOfAnotherUser(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{
+		"new@example.com/" + domain.EmailVerificationPurposeEmailChange: {
+			Email:      "new@example.com",
+			Purpose:    domain.EmailVerificationPurposeEmailChange,
+			UserID:     "1",
+			CodeHash:   secure.HashVerificationCode("ABC123"),
+			Expiration: time.Now().Add(time.Minute).Unix(),
+		},
+	}}
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, mailer.NewMemoryMailer(), validator.New(), secure.DefaultVerificationCodeLength, "")
+
+	// Another user guessing the code neither uses it up nor counts an attempt
+	for i := 0; i < 10; i++ {
+		if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeEmailChange, "2", web.EmailVerificationCreateRequest{Email: "new@example.com", Code: "ABC123"}); !errors.Is(err, service.ErrNotFound) {
+			t.Fatalf("expected the verification of another user not to be found, got %v", err)
+		}
+	}
+	if attempts := emailVerificationRepository.emailVerifications["new@example.com/"+domain.EmailVerificationPurposeEmailChange].Attempts; attempts != 0 {
+		t.Fatalf("expected no attempt to be counted, got %d", attempts)
+	}
+
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeEmailChange, "1", web.EmailVerificationCreateRequest{Email: "new@example.com", Code: "ABC123"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerificationLinkAndLegacyCode(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{}}
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, mailer.NewMemoryMailer(), validator.New(), secure.DefaultVerificationCodeLength, "")
+
+	token, hash, err := secure.NewOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	emailVerificationRepository.emailVerifications["a@example.com/"+domain.EmailVerificationPurposeSignup] = domain.EmailVerification{
+		Email:      "a@example.com",
+		Purpose:    domain.EmailVerificationPurposeSignup,
+		CodeHash:   secure.HashVerificationCode("ABC123"),
+		LinkHash:   hash,
+		Expiration: time.Now().Add(time.Minute).Unix(),
+	}
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", web.EmailVerificationCreateRequest{Email: "a@example.com", Token: "wrong"}); !errors.Is(err, service.ErrUnauthorized) {
+		t.Fatalf("expected a wrong token to be refused, got %v", err)
+	}
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", web.EmailVerificationCreateRequest{Email: "a@example.com", Token: token}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored in plaintext before codes were hashed
+	emailVerificationRepository.emailVerifications["b@example.com/"+domain.EmailVerificationPurposePasswordReset] = domain.EmailVerification{
+		Email:      "b@example.com",
+		Purpose:    domain.EmailVerificationPurposePasswordReset,
+		Code:       "XYZ789",
+		Expiration: time.Now().Add(time.Minute).Unix(),
+	}
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposePasswordReset, "", web.EmailVerificationCreateRequest{Email: "b@example.com", Token: "XYZ789"}); !errors.Is(err, service.ErrUnauthorized) {
+		t.Fatalf("expected a code not to be accepted as a token, got %v", err)
+	}
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposePasswordReset, "", web.EmailVerificationCreateRequest{Email: "b@example.com", Code: "XYZ789"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerificationEmails(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{}}
+	memoryMailer := mailer.NewMemoryMailer()
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, memoryMailer, validator.New(), 8, "https://example.com/verify")
+
+	email := domain.EmailVerificationSend{
+		FromName:  "App",
+		FromEmail: "app@example.com",
+		ToEmail:   "a@example.com",
+		Purpose:   domain.EmailVerificationPurposeSignup,
+		Title:     "Email Verification",
+	}
+	emailVerification, err := emailVerificationService.Create(email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emailVerificationService.Recreate(email); !errors.Is(err, service.ErrUnauthorized) {
+		t.Fatalf("expected no other email during the cooldown, got %v", err)
+	}
+
+	messages := memoryMailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.From.Address != "app@example.com" || message.From.Name != "App" || message.To.Address != "a@example.com" || message.Subject != "Email Verification" {
+		t.Fatalf("expected an email from the app to the user, got %+v", message)
+	}
+
+	// The email holds the code on its first line and then the link
+	lines := strings.Split(message.Body, "\r\n")
+	code, link := lines[0], lines[len(lines)-1]
+	if len(code) != 8 {
+		t.Fatalf("expected a code of 8 characters, got %q", code)
+	}
+	if emailVerification.CodeHash != secure.HashVerificationCode(code) || emailVerification.Code != "" {
+		t.Fatalf("expected only the hash of the code to be stored, got %+v", emailVerification)
+	}
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linkURL.Host != "example.com" || linkURL.Query().Get("email") != "a@example.com" || linkURL.Query().Get("token") == "" {
+		t.Fatalf("expected a link with the address and a token, got %q", link)
+	}
+
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", web.EmailVerificationCreateRequest{Email: "a@example.com", Code: code}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other purposes only get a code
+	email.Purpose = domain.EmailVerificationPurposePasswordReset
+	if _, err := emailVerificationService.Create(email); err != nil {
+		t.Fatal(err)
+	}
+	messages = memoryMailer.Messages()
+	if len(messages) != 2 || strings.Contains(messages[1].Body, "https://") {
+		t.Fatalf("expected a second email without a link, got %+v", messages)
+	}
+}
+
+type failingMailer struct {
+	err error
+}
+
+func (failing *failingMailer) Send(message mailer.Message) error {
+	return failing.err
+}
+
+func TestVerificationEmailFailure(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{}}
+	sendErr := errors.New("connection refused")
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, &failingMailer{err: sendErr}, validator.New(), secure.DefaultVerificationCodeLength, "")
+
+	email := domain.EmailVerificationSend{ToEmail: "a@example.com", Purpose: domain.EmailVerificationPurposeSignup}
+	if _, err := emailVerificationService.Create(email); !errors.Is(err, sendErr) {
+		t.Fatalf("expected the mailer error, got %v", err)
+	}
+	if len(emailVerificationRepository.emailVerifications) != 0 {
+		t.Fatal("expected no verification to be kept for an email that was not sent")
+	}
+
+	// Another email can be requested right away
+	memoryMailer := mailer.NewMemoryMailer()
+	emailVerificationService = service.NewEmailVerificationService(emailVerificationRepository, memoryMailer, validator.New(), secure.DefaultVerificationCodeLength, "")
+	if _, err := emailVerificationService.Create(email); err != nil {
+		t.Fatal(err)
+	}
+
+	// A verification that exists is not sent again
+	if _, err := emailVerificationService.Create(email); !errors.Is(err, service.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+	if len(memoryMailer.Messages()) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(memoryMailer.Messages()))
+	}
+}
+
+func TestVerificationResend(t *testing.T) {
+	emailVerificationRepository := &memoryEmailVerificationRepository{emailVerifications: map[string]domain.EmailVerification{}}
+	sendErr := errors.New("connection refused")
+	failing := &failingMailer{err: sendErr}
+	emailVerificationService := service.NewEmailVerificationService(emailVerificationRepository, failing, validator.New(), secure.DefaultVerificationCodeLength, "")
+
+	key := "a@example.com/" + domain.EmailVerificationPurposeSignup
+	emailVerificationRepository.emailVerifications[key] = domain.EmailVerification{
+		Email:      "a@example.com",
+		Purpose:    domain.EmailVerificationPurposeSignup,
+		CodeHash:   secure.HashVerificationCode("ABC123"),
+		Expiration: time.Now().Add(time.Minute).Unix(),
+		Cooldown:   time.Now().Add(-time.Second).Unix(),
+		Attempts:   3,
+	}
+
+	// A resend that cannot be sent gives the cooldown back
+	email := domain.EmailVerificationSend{ToEmail: "a@example.com", Purpose: domain.EmailVerificationPurposeSignup}
+	if _, err := emailVerificationService.Recreate(email); !errors.Is(err, sendErr) {
+		t.Fatalf("expected the mailer error, got %v", err)
+	}
+	if cooldown := emailVerificationRepository.emailVerifications[key].Cooldown; cooldown > time.Now().Unix() {
+		t.Fatalf("expected the cooldown to be given back, got %d", cooldown)
+	}
+
+	// The code that was sent is the one stored, and another resend waits for the cooldown
+	memoryMailer := mailer.NewMemoryMailer()
+	emailVerificationService = service.NewEmailVerificationService(emailVerificationRepository, memoryMailer, validator.New(), secure.DefaultVerificationCodeLength, "")
+	if _, err := emailVerificationService.Recreate(email); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := emailVerificationService.Recreate(email); !errors.Is(err, service.ErrUnauthorized) {
+		t.Fatalf("expected no other email during the cooldown, got %v", err)
+	}
+	messages := memoryMailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(messages))
+	}
+	if _, err := emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", web.EmailVerificationCreateRequest{Email: "a@example.com", Code: messages[0].Body}); err != nil {
+		t.Fatalf("expected the code that was sent to be accepted, got %v", err)
+	}
+}
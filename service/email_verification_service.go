@@ -2,15 +2,15 @@ package service
 
 import (
 	"context"
-	"encoding/base64"
+	"crypto/subtle"
 	"errors"
-	"fmt"
+	"godas/mailer"
 	"godas/model/domain"
 	"godas/model/web"
 	"godas/repository"
-	"math/rand"
+	"godas/secure"
 	"net/mail"
-	"net/smtp"
+	"net/url"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -19,82 +19,79 @@ import (
 type EmailVerificationService interface {
 	Create(domain.EmailVerificationSend) (domain.EmailVerification, error)
 	Recreate(domain.EmailVerificationSend) (domain.EmailVerification, error)
-	Verification(web.EmailVerificationCreateRequest) error
+	Verification(purpose string, userID string, request web.EmailVerificationCreateRequest) (domain.EmailVerification, error)
 }
 
+// How long a code is valid, how long to wait before another one is sent, and how many codes
+// can be tried before the code is thrown away
+const (
+	emailVerificationExpiration  = time.Minute * 10
+	emailVerificationCooldown    = time.Minute
+	emailVerificationMaxAttempts = 5
+)
+
+// Signup emails also hold a link to linkURL with the address and a token that verifies it like the code,
+// when linkURL is set
 type EmailVerificationServiceImpl struct {
 	emailVerificationRepository repository.EmailVerificationRepository
+	mailer                      mailer.Mailer
 	validate                    *validator.Validate
+	codeLength                  int
+	linkURL                     string
 }
 
-func NewEmailVerificationService(emailVerificationRepository repository.EmailVerificationRepository, validate *validator.Validate) EmailVerificationService {
+func NewEmailVerificationService(emailVerificationRepository repository.EmailVerificationRepository, mailer mailer.Mailer, validate *validator.Validate, codeLength int, linkURL string) EmailVerificationService {
 	return &EmailVerificationServiceImpl{
 		emailVerificationRepository: emailVerificationRepository,
+		mailer:                      mailer,
 		validate:                    validate,
+		codeLength:                  codeLength,
+		linkURL:                     linkURL,
 	}
 }
 
-var (
-	codeSelection       = []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	codeSelectionLength = len(codeSelection)
-)
-
-func (service *EmailVerificationServiceImpl) GenerateCode() []byte {
-	code := make([]byte, 6)
-	codeLength := len(code)
-	for i := 0; i < codeLength; i++ {
-		code[i] = codeSelection[rand.Intn(codeSelectionLength)]
-	}
-	return code
-}
-
-func (service *EmailVerificationServiceImpl) Create(email domain.EmailVerificationSend) (domain.EmailVerification, error) {
-	emailVerification := domain.EmailVerification{}
-
-	from := mail.Address{
-		Name:    email.FromName,
-		Address: email.FromEmail,
+// Generate the code of a verification and, for signups when enabled, the token of its link.
+// Only their hashes are kept in the verification, the text to send holds them.
+func (service *EmailVerificationServiceImpl) secrets(emailVerification *domain.EmailVerification) (string, error) {
+	code, err := secure.NewVerificationCode(service.codeLength)
+	if err != nil {
+		return "", err
 	}
+	emailVerification.CodeHash = secure.HashVerificationCode(code)
 
-	to := mail.Address{
-		Address: email.ToEmail,
+	if service.linkURL == "" || emailVerification.Purpose != domain.EmailVerificationPurposeSignup {
+		return code, nil
 	}
 
-	header := map[string]string{
-		"From":                      from.String(),
-		"To":                        to.String(),
-		"Subject":                   email.Title,
-		"MIME-Version":              "1.0",
-		"Content-Type":              "text/plain; charset=\"utf-8\"",
-		"Content-Transfer-Encoding": "base64",
+	token, hash, err := secure.NewOpaqueToken()
+	if err != nil {
+		return "", err
 	}
+	emailVerification.LinkHash = hash
 
-	message := ""
-	for key, value := range header {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
-	}
-	code := service.GenerateCode()
-	message += "\r\n" + base64.StdEncoding.EncodeToString(code)
+	query := url.Values{}
+	query.Set("email", emailVerification.Email)
+	query.Set("token", token)
 
-	auth := smtp.PlainAuth("", email.FromEmail, email.FromPassword, email.Host)
+	return code + "\r\n\r\n" + service.linkURL + "?" + query.Encode(), nil
+}
 
-	if err := smtp.SendMail(
-		fmt.Sprintf("%v:%v", email.Host, email.Port),
-		auth,
-		from.Address,
-		[]string{to.Address},
-		[]byte(message),
-	); err != nil {
-		return emailVerification, err
+func (service *EmailVerificationServiceImpl) Create(email domain.EmailVerificationSend) (domain.EmailVerification, error) {
+	emailVerification := domain.EmailVerification{
+		Email:      email.ToEmail,
+		Purpose:    email.Purpose,
+		UserID:     email.UserID,
+		Expiration: time.Now().Add(emailVerificationExpiration).Unix(),
+		Cooldown:   time.Now().Add(emailVerificationCooldown).Unix(),
 	}
 
-	emailVerification = domain.EmailVerification{
-		Email:      email.ToEmail,
-		Code:       string(code),
-		Expiration: time.Now().Add(time.Minute * 10).Unix(),
-		Cooldown:   time.Now().Add(time.Minute).Unix(),
+	body, err := service.secrets(&emailVerification)
+	if err != nil {
+		return emailVerification, err
 	}
 
+	// The verification is stored first so that no email is sent for a verification that already exists,
+	// and removed again when the email cannot be sent so that another one can be requested right away
 	if err := service.emailVerificationRepository.Insert(context.Background(), emailVerification); err != nil {
 		if errors.Is(err, repository.ErrDuplicateData) {
 			return emailVerification, ErrDuplicate
@@ -102,103 +99,113 @@ func (service *EmailVerificationServiceImpl) Create(email domain.EmailVerificati
 		return emailVerification, err
 	}
 
+	if sendErr := service.send(email, body); sendErr != nil {
+		if err := service.emailVerificationRepository.Delete(context.Background(), emailVerification.Email, emailVerification.Purpose); err != nil && !errors.Is(err, repository.ErrNoData) {
+			return emailVerification, err
+		}
+		return emailVerification, sendErr
+	}
+
 	return emailVerification, nil
 }
 
+// Replace the code of a verification once its cooldown is over. The cooldown is claimed together with the
+// new code before the email is sent, so concurrent requests send a single email and only a stored code is sent.
+// The cooldown is given back when the email cannot be sent so that another one can be requested right away.
 func (service *EmailVerificationServiceImpl) Recreate(email domain.EmailVerificationSend) (domain.EmailVerification, error) {
-	emailVerification, err := service.emailVerificationRepository.FindByEmail(context.Background(), email.ToEmail)
+	now := time.Now()
+	emailVerification := domain.EmailVerification{
+		Email:      email.ToEmail,
+		Purpose:    email.Purpose,
+		UserID:     email.UserID,
+		Expiration: now.Add(emailVerificationExpiration).Unix(),
+		Cooldown:   now.Add(emailVerificationCooldown).Unix(),
+		Attempts:   0,
+	}
+
+	body, err := service.secrets(&emailVerification)
 	if err != nil {
+		return emailVerification, err
+	}
+
+	if err := service.emailVerificationRepository.Renew(context.Background(), emailVerification, now.Unix()); err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return emailVerification, ErrNotFound
+		} else if errors.Is(err, repository.ErrLockedData) {
+			return emailVerification, ErrUnauthorized
 		}
 		return emailVerification, err
 	}
 
-	if time.Now().Unix() < emailVerification.Cooldown {
-		return emailVerification, ErrUnauthorized
-	}
-
-	emailVerification.Expiration = time.Now().Add(time.Minute * 10).Unix()
-	emailVerification.Cooldown = time.Now().Add(time.Minute * 1).Unix()
-
-	from := mail.Address{
-		Name:    email.FromName,
-		Address: email.FromEmail,
-	}
-
-	to := mail.Address{
-		Address: email.ToEmail,
-	}
-
-	header := map[string]string{
-		"From":                      from.String(),
-		"To":                        to.String(),
-		"Subject":                   email.Title,
-		"MIME-Version":              "1.0",
-		"Content-Type":              "text/plain; charset=\"utf-8\"",
-		"Content-Transfer-Encoding": "base64",
+	if sendErr := service.send(email, body); sendErr != nil {
+		emailVerification.Cooldown = now.Unix()
+		if _, err := service.emailVerificationRepository.Update(context.Background(), emailVerification); err != nil && !errors.Is(err, repository.ErrNoData) {
+			return emailVerification, err
+		}
+		return emailVerification, sendErr
 	}
 
-	message := ""
-	for key, value := range header {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
-	}
-	code := service.GenerateCode()
-	message += "\r\n" + base64.StdEncoding.EncodeToString(code)
-	emailVerification.Code = string(code)
+	return emailVerification, nil
+}
 
-	auth := smtp.PlainAuth("", email.FromEmail, email.FromPassword, email.Host)
+func (service *EmailVerificationServiceImpl) send(email domain.EmailVerificationSend, body string) error {
+	return service.mailer.Send(mailer.Message{
+		From:    mail.Address{Name: email.FromName, Address: email.FromEmail},
+		To:      mail.Address{Address: email.ToEmail},
+		Subject: email.Title,
+		Body:    body,
+	})
+}
 
-	if err := smtp.SendMail(
-		fmt.Sprintf("%v:%v", email.Host, email.Port),
-		auth,
-		from.Address,
-		[]string{to.Address},
-		[]byte(message),
-	); err != nil {
-		return emailVerification, err
+// Check a code sent for a purpose, a code is deleted once it is used.
+// A code is deleted too after too many wrong guesses, a new one has to be sent.
+// A user id restricts the check to the verification asked for by that user, others cannot use up its attempts.
+func (service *EmailVerificationServiceImpl) Verification(purpose string, userID string, request web.EmailVerificationCreateRequest) (domain.EmailVerification, error) {
+	if err := service.validate.Struct(request); err != nil {
+		return domain.EmailVerification{}, ErrBadRequest
 	}
 
-	res, err := service.emailVerificationRepository.Update(context.Background(), emailVerification)
+	emailVerification, err := service.emailVerificationRepository.Attempt(context.Background(), request.Email, purpose, userID)
 	if err != nil {
-		if errors.Is(err, repository.ErrDuplicateData) {
-			return emailVerification, ErrDuplicate
-		} else if errors.Is(err, repository.ErrNoData) {
+		if errors.Is(err, repository.ErrNoData) {
 			return emailVerification, ErrNotFound
 		}
 		return emailVerification, err
 	}
 
-	return res, nil
-}
+	if emailVerification.Attempts > emailVerificationMaxAttempts || time.Now().Unix() >= emailVerification.Expiration {
+		return emailVerification, ErrUnauthorized
+	}
 
-func (service *EmailVerificationServiceImpl) Verification(request web.EmailVerificationCreateRequest) error {
-	if err := service.validate.Struct(request); err != nil {
-		return ErrBadRequest
+	if !verificationMatches(emailVerification, request) {
+		if emailVerification.Attempts == emailVerificationMaxAttempts {
+			if err := service.emailVerificationRepository.Delete(context.Background(), request.Email, purpose); err != nil && !errors.Is(err, repository.ErrNoData) {
+				return emailVerification, err
+			}
+		}
+		return emailVerification, ErrUnauthorized
 	}
 
-	emailVerification, err := service.emailVerificationRepository.FindByEmail(context.Background(), request.Email)
-	if err != nil {
+	if err := service.emailVerificationRepository.Delete(context.Background(), request.Email, purpose); err != nil {
 		if errors.Is(err, repository.ErrNoData) {
-			return ErrNotFound
+			return emailVerification, ErrNotFound
 		}
-		return err
+		return emailVerification, err
 	}
 
-	if time.Now().Unix() >= emailVerification.Expiration {
-		return ErrUnauthorized
-	}
+	return emailVerification, nil
+}
 
-	if request.Code != emailVerification.Code {
-		return ErrUnauthorized
+// A request holds either the code or the token of the link. Codes stored before they were hashed are compared as is.
+func verificationMatches(emailVerification domain.EmailVerification, request web.EmailVerificationCreateRequest) bool {
+	if request.Token != "" {
+		return emailVerification.LinkHash != "" &&
+			subtle.ConstantTimeCompare([]byte(secure.HashOpaqueToken(request.Token)), []byte(emailVerification.LinkHash)) == 1
 	}
 
-	if err := service.emailVerificationRepository.Delete(context.Background(), request.Email); err != nil {
-		if errors.Is(err, repository.ErrNoData) {
-			return ErrNotFound
-		}
-		return err
+	if emailVerification.CodeHash != "" {
+		return subtle.ConstantTimeCompare([]byte(secure.HashVerificationCode(request.Code)), []byte(emailVerification.CodeHash)) == 1
 	}
 
-	return nil
+	return emailVerification.Code != "" && subtle.ConstantTimeCompare([]byte(request.Code), []byte(emailVerification.Code)) == 1
 }
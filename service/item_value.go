@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"godas/model/domain"
+	"godas/model/web"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Default limit of the raw JSON size of an item value in bytes
+const DefaultItemMaxSize = 16 * 1024
+
+var errInvalidItemValue = errors.New("invalid item value")
+
+// Convert a raw JSON value to BSON without losing information.
+// Object keys keep their order, integers become int64 and every other number is kept
+// as a decimal so that it is written back without losing precision, only the notation
+// of exponents is normalized. Numbers a decimal cannot hold exactly are refused.
+func decodeItemValue(raw json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errInvalidItemValue
+	}
+
+	return value, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			document := bson.D{}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				document = append(document, bson.E{Key: key.(string), Value: value})
+			}
+			_, err := decoder.Token()
+			return document, err
+		case '[':
+			array := bson.A{}
+			for decoder.More() {
+				value, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			_, err := decoder.Token()
+			return array, err
+		}
+	case json.Number:
+		// An int64 has no negative zero
+		if integer, err := token.Int64(); err == nil && token.String() != "-0" {
+			return integer, nil
+		}
+		return parseExactDecimal(token.String())
+	case string, bool, nil:
+		return token, nil
+	}
+
+	return nil, errInvalidItemValue
+}
+
+// A decimal holds 34 significant digits, a number is refused when its digits, exponent or sign
+// would not be written back as they were sent
+func parseExactDecimal(number string) (primitive.Decimal128, error) {
+	decimal, err := primitive.ParseDecimal128(number)
+	if err != nil {
+		return decimal, errInvalidItemValue
+	}
+	coefficient, exponent, err := decimal.BigInt()
+	if err != nil {
+		return decimal, errInvalidItemValue
+	}
+
+	mantissa, exponentText, hasExponent := strings.Cut(strings.ToLower(number), "e")
+	expectedExponent := 0
+	if hasExponent {
+		if expectedExponent, err = strconv.Atoi(exponentText); err != nil {
+			return decimal, errInvalidItemValue
+		}
+	}
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	expectedExponent -= len(fraction)
+	expectedCoefficient, isNumber := new(big.Int).SetString(integer+fraction, 10)
+	if !isNumber {
+		return decimal, errInvalidItemValue
+	}
+
+	if coefficient.Cmp(expectedCoefficient) != 0 || exponent != expectedExponent ||
+		strings.HasPrefix(number, "-") != strings.HasPrefix(decimal.String(), "-") {
+		return decimal, errInvalidItemValue
+	}
+
+	return decimal, nil
+}
+
+// Convert a BSON value written by decodeItemValue back to raw JSON
+func encodeItemValue(value any) (json.RawMessage, error) {
+	buffer := new(bytes.Buffer)
+	if err := encodeJSONValue(buffer, value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func encodeJSONValue(buffer *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case primitive.D:
+		buffer.WriteByte('{')
+		for i, element := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			key, _ := json.Marshal(element.Key)
+			buffer.Write(key)
+			buffer.WriteByte(':')
+			if err := encodeJSONValue(buffer, element.Value); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+	case primitive.A:
+		buffer.WriteByte('[')
+		for i, element := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJSONValue(buffer, element); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+	case int32:
+		buffer.WriteString(strconv.FormatInt(int64(value), 10))
+	case int64:
+		buffer.WriteString(strconv.FormatInt(value, 10))
+	case float64:
+		buffer.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	case primitive.Decimal128:
+		buffer.WriteString(value.String())
+	case string:
+		encoded, _ := json.Marshal(value)
+		buffer.Write(encoded)
+	case bool:
+		buffer.WriteString(strconv.FormatBool(value))
+	case nil:
+		buffer.WriteString("null")
+	default:
+		return fmt.Errorf("%w: unsupported type %T", errInvalidItemValue, value)
+	}
+
+	return nil
+}
+
+func compileSchema(schema string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile("schema.json")
+}
+
+// Validate a raw item value against a compiled schema, a missing value is validated as null
+func validateItemValue(schema *jsonschema.Schema, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage("null")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	return schema.Validate(value)
+}
+
+func newItemResponse(item domain.Item) (web.ItemResponse, error) {
+	response := web.ItemResponse{
+		Index: item.Index,
+		Name:  item.Name,
+	}
+
+	if item.Value != nil {
+		value, err := encodeItemValue(item.Value)
+		if err != nil {
+			return response, err
+		}
+		response.Value = value
+	} else if item.HasValue {
+		response.Value = json.RawMessage("null")
+	}
+
+	return response, nil
+}
+
+func newItemResponses(items []domain.Item) ([]web.ItemResponse, error) {
+	response := []web.ItemResponse{}
+	for _, item := range items {
+		itemResponse, err := newItemResponse(item)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, itemResponse)
+	}
+
+	return response, nil
+}
+
+func newItem(request web.ItemRequest, maxSize int) (domain.Item, error) {
+	item := domain.Item{
+		Name: request.Name,
+	}
+
+	if len(request.Value) > 0 {
+		if len(request.Value) > maxSize {
+			return item, ErrBadRequest
+		}
+		value, err := decodeItemValue(request.Value)
+		if err != nil {
+			return item, ErrBadRequest
+		}
+		item.Value = value
+		item.HasValue = true
+	}
+
+	return item, nil
+}
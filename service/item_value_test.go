@@ -0,0 +1,132 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestItemValueRoundTrip(t *testing.T) {
+	values := []string{
+		`{"b":1,"a":[true,false,null],"c":{"nested":"é\"x"}}`,
+		`[1.50,-0.0,0.1,12345678901234567890,-9223372036854775808]`,
+		`[-0,1E+5,-0.0015,1234567890123456789012345678901234]`,
+		`"plain"`,
+		`{"$set":{"a.b":1}}`,
+		`[]`,
+		`{}`,
+	}
+
+	for _, value := range values {
+		decoded, err := decodeItemValue(json.RawMessage(value))
+		if err != nil {
+			t.Fatalf("decode %s: %v", value, err)
+		}
+
+		// Go through BSON the same way a stored item does
+		document, err := bson.Marshal(bson.M{"value": decoded})
+		if err != nil {
+			t.Fatalf("marshal %s: %v", value, err)
+		}
+		stored := struct {
+			Value any `bson:"value"`
+		}{}
+		if err := bson.Unmarshal(document, &stored); err != nil {
+			t.Fatalf("unmarshal %s: %v", value, err)
+		}
+
+		encoded, err := encodeItemValue(stored.Value)
+		if err != nil {
+			t.Fatalf("encode %s: %v", value, err)
+		}
+
+		expected := new(json.RawMessage)
+		json.Unmarshal([]byte(value), expected)
+		compact, _ := json.Marshal(expected)
+		if string(encoded) != string(compact) {
+			t.Fatalf("expected %s, got %s", compact, encoded)
+		}
+	}
+}
+
+func TestItemValueInvalid(t *testing.T) {
+	for _, value := range []string{
+		`{"a":}`,
+		`1 2`,
+		`[1,`,
+		// Numbers a decimal cannot hold exactly
+		`12345678901234567890123456789012345`,
+		`12345678901234567890123456789012340000`,
+		`0.12345678901234567890123456789012345678`,
+		`1e7000`,
+	} {
+		if _, err := decodeItemValue(json.RawMessage(value)); err == nil {
+			t.Fatalf("expected %s to be rejected", value)
+		}
+		if _, err := newItem(web.ItemRequest{Value: json.RawMessage(value)}, DefaultItemMaxSize); !errors.Is(err, ErrBadRequest) {
+			t.Fatalf("expected %s to be a bad request, got %v", value, err)
+		}
+	}
+}
+
+func TestValidateItemValue(t *testing.T) {
+	schema, err := compileSchema(`{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateItemValue(schema, json.RawMessage(`{"id":12345678901234567890}`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{`{"id":"1"}`, `{}`, ``} {
+		if err := validateItemValue(schema, json.RawMessage(value)); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+
+	if _, err := compileSchema(`{"type":1}`); err == nil {
+		t.Fatal("expected an invalid schema to be rejected")
+	}
+}
+
+func TestItemNullValue(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected string
+	}{
+		{`null`, `{"index":0,"name":"a","value":null}`},
+		{``, `{"index":0,"name":"a"}`},
+		{`0`, `{"index":0,"name":"a","value":0}`},
+	} {
+		item, err := newItem(web.ItemRequest{Name: "a", Value: json.RawMessage(test.value)}, DefaultItemMaxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Go through BSON the same way a stored item does
+		document, err := bson.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored := domain.Item{}
+		if err := bson.Unmarshal(document, &stored); err != nil {
+			t.Fatal(err)
+		}
+
+		response, err := newItemResponse(stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(encoded) != test.expected {
+			t.Fatalf("expected %s for %q, got %s", test.expected, test.value, encoded)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/repository"
+	"strings"
+	"time"
+)
+
+// Failed sign ins allowed for an account and for an address before they are refused for a while.
+// Every further failure doubles the lockout, from loginLockoutBase up to loginLockoutMax.
+// Failures are forgotten once there has been none for loginAttemptWindow.
+const (
+	loginAccountFailures = 5
+	loginAddressFailures = 20
+	loginLockoutBase     = time.Second
+	loginLockoutMax      = time.Minute * 15
+	loginAttemptWindow   = time.Hour
+)
+
+type loginKey struct {
+	id          string
+	maxFailures int
+}
+
+// Keys of a sign in, the address is only known for requests made over HTTP
+func loginKeys(email string, ip string) []loginKey {
+	keys := []loginKey{accountLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, loginKey{id: "ip:" + ip, maxFailures: loginAddressFailures})
+	}
+
+	return keys
+}
+
+func accountLoginKey(email string) loginKey {
+	return loginKey{id: "account:" + strings.ToLower(email), maxFailures: loginAccountFailures}
+}
+
+// Refuse a sign in with ErrTooManyRequests while any of its keys is locked
+func checkLoginAttempts(ctx context.Context, loginAttemptRepository repository.LoginAttemptRepository, keys []loginKey, now time.Time) error {
+	ids := []string{}
+	for _, key := range keys {
+		ids = append(ids, key.id)
+	}
+
+	loginAttempts, err := loginAttemptRepository.FindByIds(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, loginAttempt := range loginAttempts {
+		if now.Before(loginAttempt.LockedUntil) {
+			return ErrTooManyRequests
+		}
+	}
+
+	return nil
+}
+
+func failLoginAttempts(ctx context.Context, loginAttemptRepository repository.LoginAttemptRepository, keys []loginKey, now time.Time) error {
+	for _, key := range keys {
+		loginAttempt, err := loginAttemptRepository.Fail(ctx, key.id, now.Add(loginAttemptWindow))
+		if err != nil {
+			return err
+		}
+		if loginAttempt.Failures < key.maxFailures {
+			continue
+		}
+		if err := loginAttemptRepository.Lock(ctx, key.id, now.Add(loginLockout(loginAttempt.Failures-key.maxFailures))); err != nil && !errors.Is(err, repository.ErrNoData) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lockout after the given number of failures beyond the allowed ones
+func loginLockout(excess int) time.Duration {
+	lockout := loginLockoutBase
+	for i := 0; i < excess && lockout < loginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > loginLockoutMax {
+		lockout = loginLockoutMax
+	}
+
+	return lockout
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/secure"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type memoryLoginAttemptRepository struct {
+	loginAttempts map[string]domain.LoginAttempt
+}
+
+func newMemoryLoginAttemptRepository() *memoryLoginAttemptRepository {
+	return &memoryLoginAttemptRepository{loginAttempts: map[string]domain.LoginAttempt{}}
+}
+
+func (memory *memoryLoginAttemptRepository) FindByIds(ctx context.Context, ids []string) ([]domain.LoginAttempt, error) {
+	loginAttempts := []domain.LoginAttempt{}
+	for _, id := range ids {
+		if loginAttempt, found := memory.loginAttempts[id]; found {
+			loginAttempts = append(loginAttempts, loginAttempt)
+		}
+	}
+	return loginAttempts, nil
+}
+
+func (memory *memoryLoginAttemptRepository) Fail(ctx context.Context, id string, expiresAt time.Time) (domain.LoginAttempt, error) {
+	loginAttempt := memory.loginAttempts[id]
+	loginAttempt.ID = id
+	loginAttempt.Failures++
+	loginAttempt.ExpiresAt = expiresAt
+	memory.loginAttempts[id] = loginAttempt
+	return loginAttempt, nil
+}
+
+func (memory *memoryLoginAttemptRepository) Lock(ctx context.Context, id string, until time.Time) error {
+	loginAttempt := memory.loginAttempts[id]
+	loginAttempt.LockedUntil = until
+	memory.loginAttempts[id] = loginAttempt
+	return nil
+}
+
+func (memory *memoryLoginAttemptRepository) Delete(ctx context.Context, id string) error {
+	delete(memory.loginAttempts, id)
+	return nil
+}
+
+func TestLoginLockout(t *testing.T) {
+	expected := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 8}
+	for excess, lockout := range expected {
+		if got := loginLockout(excess); got != lockout {
+			t.Fatalf("expected %v after %d failures, got %v", lockout, excess, got)
+		}
+	}
+	if got := loginLockout(1000); got != loginLockoutMax {
+		t.Fatalf("expected the lockout to be capped at %v, got %v", loginLockoutMax, got)
+	}
+}
+
+func TestSigninThrottling(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := passwordHasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Password: hash, Verified: true},
+	}}
+	loginAttemptRepository := newMemoryLoginAttemptRepository()
+	now := time.Unix(1700000000, 0)
+
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, nil, nil, nil, nil, loginAttemptRepository, nil, validator.New(), secure.NewJWTProvider(time.Minute, "test", "key"), passwordHasher, time.Hour).(*AuthServiceImpl)
+	authService.now = func() time.Time { return now }
+
+	wrong := web.AuthRequest{Email: "a@example.com", Password: "wrong password", IP: "10.0.0.1"}
+	right := web.AuthRequest{Email: "a@example.com", Password: "password", IP: "10.0.0.2"}
+
+	for i := 0; i < loginAccountFailures; i++ {
+		if _, err := authService.Signin(wrong); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected a wrong password to be refused, got %v", err)
+		}
+	}
+
+	// The account is locked from any address, even with the right password
+	if _, err := authService.Signin(right); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected the account to be locked, got %v", err)
+	}
+
+	now = now.Add(loginLockout(0))
+	if _, err := authService.Signin(wrong); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a wrong password to be refused, got %v", err)
+	}
+	now = now.Add(loginLockout(0))
+	if _, err := authService.Signin(right); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected the lockout to double, got %v", err)
+	}
+
+	now = now.Add(loginLockout(1))
+	if _, err := authService.Signin(right); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := loginAttemptRepository.loginAttempts[accountLoginKey("a@example.com").id]; found {
+		t.Fatal("expected the failures of the account to be forgotten after a sign in")
+	}
+	if _, found := loginAttemptRepository.loginAttempts["ip:10.0.0.1"]; !found {
+		t.Fatal("expected the failures of the address to be kept")
+	}
+
+	// Guessing many accounts from one address locks the address
+	for i := 0; i < loginAddressFailures; i++ {
+		if _, err := authService.Signin(web.AuthRequest{Email: fmt.Sprintf("%d@example.com", i), Password: "password", IP: "10.0.0.3"}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected an unknown account to be refused, got %v", err)
+		}
+	}
+	if _, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password", IP: "10.0.0.3"}); !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected the address to be locked, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/secure"
+	"os"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type MFAService interface {
+	Enroll(userID string) (web.MFAEnrollResponse, error)
+	Confirm(userID string, request web.MFAConfirmRequest) error
+	Disable(userID string, request web.MFADisableRequest) error
+}
+
+// A sign in waiting for its second factor expires quickly and only allows a few codes to be tried
+const (
+	mfaChallengeExpiration  = time.Minute * 5
+	mfaChallengeMaxAttempts = 5
+)
+
+type MFAServiceImpl struct {
+	userRepository repository.UserRepository
+	validate       *validator.Validate
+	passwordHasher *secure.PasswordHasher
+	now            func() time.Time
+}
+
+func NewMFAService(userRepository repository.UserRepository, validate *validator.Validate, passwordHasher *secure.PasswordHasher) MFAService {
+	mfaService := new(MFAServiceImpl)
+	mfaService.userRepository = userRepository
+	mfaService.validate = validate
+	mfaService.passwordHasher = passwordHasher
+	mfaService.now = time.Now
+
+	return mfaService
+}
+
+// Generate a new secret and recovery codes, they are only used once a code confirms the enrollment.
+// Enrolling again before confirming replaces the pending secret.
+func (service *MFAServiceImpl) Enroll(userID string) (web.MFAEnrollResponse, error) {
+	response := web.MFAEnrollResponse{}
+
+	user, err := service.userRepository.FindById(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+	if user.TOTPEnabled {
+		return response, ErrDuplicate
+	}
+
+	secret, err := secure.NewTOTPSecret()
+	if err != nil {
+		return response, err
+	}
+	codes, hashes, err := secure.NewRecoveryCodes()
+	if err != nil {
+		return response, err
+	}
+
+	if err := service.userRepository.SetTOTP(context.Background(), user.ID, secret, hashes); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrDuplicate
+		}
+		return response, err
+	}
+
+	response = web.MFAEnrollResponse{
+		Secret:        secret,
+		URI:           secure.TOTPURI(os.Getenv("APP_NAME"), user.Email, secret),
+		RecoveryCodes: codes,
+	}
+
+	return response, nil
+}
+
+// Turn two-factor authentication on with a code of the pending secret
+func (service *MFAServiceImpl) Confirm(userID string, request web.MFAConfirmRequest) error {
+	if err := service.validate.Struct(request); err != nil {
+		return ErrBadRequest
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if user.TOTPEnabled {
+		return ErrDuplicate
+	}
+	if user.TOTPSecret == "" {
+		return ErrNotFound
+	}
+
+	step, match := secure.VerifyTOTP(user.TOTPSecret, request.Code, service.now())
+	if !match {
+		return ErrUnauthorized
+	}
+
+	if err := service.userRepository.EnableTOTP(context.Background(), user.ID, step); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (service *MFAServiceImpl) Disable(userID string, request web.MFADisableRequest) error {
+	if err := service.validate.Struct(request); err != nil {
+		return ErrBadRequest
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrNotFound
+	}
+	if match, _ := service.passwordHasher.Verify(user.Password, request.Password); !match {
+		return ErrUnauthorized
+	}
+	if err := useSecondFactor(context.Background(), service.userRepository, user, request.Code, service.now()); err != nil {
+		return err
+	}
+
+	if err := service.userRepository.DisableTOTP(context.Background(), user.ID); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Accept a TOTP code or a recovery code of a user with two-factor authentication enabled.
+// A TOTP code is refused when a code of its period was already used, a recovery code is removed once used.
+func useSecondFactor(ctx context.Context, userRepository repository.UserRepository, user domain.User, code string, at time.Time) error {
+	if step, match := secure.VerifyTOTP(user.TOTPSecret, code, at); match {
+		if err := userRepository.UseTOTPStep(ctx, user.ID, step); err != nil {
+			if errors.Is(err, repository.ErrUsedData) {
+				return ErrUnauthorized
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := userRepository.UseRecoveryCode(ctx, user.ID, secure.HashRecoveryCode(code)); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrUnauthorized
+		}
+		return err
+	}
+
+	return nil
+}
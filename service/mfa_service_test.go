@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/secure"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type memoryUserRepository struct {
+	repository.UserRepository
+	users map[string]domain.User
+}
+
+func (memory *memoryUserRepository) FindById(ctx context.Context, id string) (domain.User, error) {
+	user, found := memory.users[id]
+	if !found {
+		return user, repository.ErrNoData
+	}
+	return user, nil
+}
+
+func (memory *memoryUserRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	for _, user := range memory.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return domain.User{}, repository.ErrNoData
+}
+
+func (memory *memoryUserRepository) Insert(ctx context.Context, user domain.User) (domain.User, error) {
+	for _, other := range memory.users {
+		if other.Email == user.Email {
+			return user, repository.ErrDuplicateData
+		}
+	}
+	user.ID = strconv.Itoa(len(memory.users) + 1)
+	memory.users[user.ID] = user
+	return user, nil
+}
+
+func (memory *memoryUserRepository) SetTOTP(ctx context.Context, id string, secret string, recoveryCodes []string) error {
+	user := memory.users[id]
+	if user.TOTPEnabled {
+		return repository.ErrNoData
+	}
+	user.TOTPSecret, user.RecoveryCodes = secret, recoveryCodes
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) EnableTOTP(ctx context.Context, id string, step int64) error {
+	user := memory.users[id]
+	user.TOTPEnabled, user.TOTPLastStep = true, step
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) DisableTOTP(ctx context.Context, id string) error {
+	user := memory.users[id]
+	user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep, user.RecoveryCodes = "", false, 0, nil
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) UseTOTPStep(ctx context.Context, id string, step int64) error {
+	user := memory.users[id]
+	if user.TOTPLastStep >= step {
+		return repository.ErrUsedData
+	}
+	user.TOTPLastStep = step
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) UseRecoveryCode(ctx context.Context, id string, hash string) error {
+	user := memory.users[id]
+	for i, code := range user.RecoveryCodes {
+		if code == hash {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			memory.users[id] = user
+			return nil
+		}
+	}
+	return repository.ErrNoData
+}
+
+func (memory *memoryUserRepository) SetRoles(ctx context.Context, id string, roles []string, at time.Time) error {
+	user, found := memory.users[id]
+	if !found {
+		return repository.ErrNoData
+	}
+	user.Roles, user.TokensRevokedAt = roles, at
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) SetPassword(ctx context.Context, id string, password string) error {
+	user, found := memory.users[id]
+	if !found {
+		return repository.ErrNoData
+	}
+	user.Password = password
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) SetVerified(ctx context.Context, id string) error {
+	user, found := memory.users[id]
+	if !found {
+		return repository.ErrNoData
+	}
+	user.Verified = true
+	memory.users[id] = user
+	return nil
+}
+
+func (memory *memoryUserRepository) RevokeTokens(ctx context.Context, id string, at time.Time) error {
+	user, found := memory.users[id]
+	if !found {
+		return repository.ErrNoData
+	}
+	// Dates are stored in milliseconds
+	user.TokensRevokedAt = at.Truncate(time.Millisecond)
+	memory.users[id] = user
+	return nil
+}
+
+type memoryMFAChallengeRepository struct {
+	challenges map[string]domain.MFAChallenge
+}
+
+func (memory *memoryMFAChallengeRepository) Insert(ctx context.Context, challenge domain.MFAChallenge) error {
+	memory.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (memory *memoryMFAChallengeRepository) Attempt(ctx context.Context, id string, maxAttempts int) (domain.MFAChallenge, error) {
+	challenge, found := memory.challenges[id]
+	if !found || challenge.Attempts >= maxAttempts {
+		return domain.MFAChallenge{}, repository.ErrNoData
+	}
+	challenge.Attempts++
+	memory.challenges[id] = challenge
+	return challenge, nil
+}
+
+func (memory *memoryMFAChallengeRepository) Delete(ctx context.Context, id string) error {
+	if _, found := memory.challenges[id]; !found {
+		return repository.ErrNoData
+	}
+	delete(memory.challenges, id)
+	return nil
+}
+
+type memoryRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+}
+
+func (memory *memoryRefreshTokenRepository) Insert(ctx context.Context, refreshToken domain.RefreshToken) error {
+	return nil
+}
+
+func (memory *memoryRefreshTokenRepository) DeleteByUser(ctx context.Context, userID string) error {
+	return nil
+}
+
+func TestMFAEnrollmentAndSignin(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := passwordHasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Password: hash, Verified: true},
+	}}
+	mfaChallengeRepository := &memoryMFAChallengeRepository{challenges: map[string]domain.MFAChallenge{}}
+	validate := validator.New()
+
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+
+	mfaService := NewMFAService(userRepository, validate, passwordHasher).(*MFAServiceImpl)
+	mfaService.now = clock
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, nil, nil, nil, mfaChallengeRepository, newMemoryLoginAttemptRepository(), nil, validate, secure.NewJWTProvider(time.Minute, "test", "key"), passwordHasher, time.Hour).(*AuthServiceImpl)
+	authService.now = clock
+
+	enrollment, err := mfaService.Enroll("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enrollment.RecoveryCodes) == 0 {
+		t.Fatal("expected recovery codes")
+	}
+
+	// A pending enrollment does not change how the user signs in
+	token, err := authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.MFARequired || token.AccessToken == "" {
+		t.Fatalf("expected tokens before the enrollment is confirmed, got %+v", token)
+	}
+
+	if err := mfaService.Confirm("1", web.MFAConfirmRequest{Code: "000000"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a wrong code to be refused, got %v", err)
+	}
+	code, err := secure.TOTPCode(enrollment.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mfaService.Confirm("1", web.MFAConfirmRequest{Code: code}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !token.MFARequired || token.MFAToken == "" || token.AccessToken != "" {
+		t.Fatalf("expected a pending sign in, got %+v", token)
+	}
+
+	// The code that confirmed the enrollment cannot be used again
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: code}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a replayed code to be refused, got %v", err)
+	}
+
+	now = now.Add(time.Second * 30)
+	code, err = secure.TOTPCode(enrollment.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedIn, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedIn.AccessToken == "" || signedIn.RefreshToken == "" {
+		t.Fatalf("expected tokens, got %+v", signedIn)
+	}
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: code}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected the challenge to be used once, got %v", err)
+	}
+
+	// Recovery codes are single-use
+	token, err = authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: enrollment.RecoveryCodes[0]}); err != nil {
+		t.Fatal(err)
+	}
+	token, err = authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: enrollment.RecoveryCodes[0]}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a used recovery code to be refused, got %v", err)
+	}
+
+	// Challenges expire
+	now = now.Add(mfaChallengeExpiration)
+	code, err = secure.TOTPCode(enrollment.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: code}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected an expired challenge to be refused, got %v", err)
+	}
+
+	if err := mfaService.Disable("1", web.MFADisableRequest{Password: "password", Code: enrollment.RecoveryCodes[1]}); err != nil {
+		t.Fatal(err)
+	}
+	token, err = authService.Signin(web.AuthRequest{Email: "a@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.MFARequired {
+		t.Fatal("expected two-factor authentication to be disabled")
+	}
+}
+
+func TestMFASigninAttempts(t *testing.T) {
+	mfaChallengeRepository := &memoryMFAChallengeRepository{challenges: map[string]domain.MFAChallenge{}}
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Verified: true, TOTPEnabled: true, TOTPSecret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"},
+	}}
+	now := time.Unix(1700000000, 0)
+
+	authService := NewAuthService(userRepository, &memoryRefreshTokenRepository{}, nil, nil, nil, mfaChallengeRepository, newMemoryLoginAttemptRepository(), nil, validator.New(), secure.NewJWTProvider(time.Minute, "test", "key"), nil, time.Hour).(*AuthServiceImpl)
+	authService.now = func() time.Time { return now }
+
+	token, err := authService.challenge(userRepository.users["1"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < mfaChallengeMaxAttempts; i++ {
+		if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: "wrong"}); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected a wrong code to be refused, got %v", err)
+		}
+	}
+
+	code, err := secure.TOTPCode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.SigninMFA(web.MFASigninRequest{MFAToken: token.MFAToken, Code: code}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected the challenge to be refused after too many attempts, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/structure"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type OrderedMapService interface {
+	Create(string, web.OrderedMapCreateRequest) (web.OrderedMapResponse, error)
+	FindByIdFromOwner(id string, owner string) (web.OrderedMapResponse, error)
+	FindAllFromOwner(string) ([]web.OrderedMapResponse, error)
+	PutFromOwner(id string, owner string, key int64, request web.OrderedMapEntryRequest) (web.OrderedMapMutationResponse, error)
+	GetFromOwner(id string, owner string, key int64) (web.OrderedMapEntryResponse, error)
+	DeleteFromOwner(id string, owner string, key int64) (web.OrderedMapMutationResponse, error)
+	EntriesFromOwner(id string, owner string) ([]web.OrderedMapEntryResponse, error)
+}
+
+type OrderedMapServiceImpl struct {
+	orderedMapRepository repository.OrderedMapRepository
+	userRepository       repository.UserRepository
+	validate             *validator.Validate
+}
+
+func NewOrderedMapService(orderedMapRepository repository.OrderedMapRepository, userRepository repository.UserRepository, validate *validator.Validate) OrderedMapService {
+	service := new(OrderedMapServiceImpl)
+	service.orderedMapRepository = orderedMapRepository
+	service.userRepository = userRepository
+	service.validate = validate
+
+	return service
+}
+
+func (service *OrderedMapServiceImpl) Create(id string, request web.OrderedMapCreateRequest) (web.OrderedMapResponse, error) {
+	response := web.OrderedMapResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	orderedMap, err := service.orderedMapRepository.Insert(context.Background(), domain.OrderedMap{
+		Kind:  request.Kind,
+		Nodes: []domain.OrderedMapNode{},
+		Owner: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateData) {
+			return response, ErrDuplicate
+		}
+		return response, err
+	}
+
+	return newOrderedMapResponse(orderedMap)
+}
+
+func (service *OrderedMapServiceImpl) FindByIdFromOwner(id string, owner string) (web.OrderedMapResponse, error) {
+	orderedMap, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return web.OrderedMapResponse{}, err
+	}
+
+	return newOrderedMapResponse(orderedMap)
+}
+
+func (service *OrderedMapServiceImpl) FindAllFromOwner(owner string) ([]web.OrderedMapResponse, error) {
+	orderedMaps, err := service.orderedMapRepository.FindByOwner(context.Background(), owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	response := []web.OrderedMapResponse{}
+	for _, orderedMap := range orderedMaps {
+		orderedMapResponse, err := newOrderedMapResponse(orderedMap)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, orderedMapResponse)
+	}
+
+	return response, nil
+}
+
+func (service *OrderedMapServiceImpl) PutFromOwner(id string, owner string, key int64, request web.OrderedMapEntryRequest) (web.OrderedMapMutationResponse, error) {
+	response := web.OrderedMapMutationResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	var inserted bool
+	var rotations []domain.Rotation
+	if err := service.update(id, owner, func(tree structure.OrderedMap) error {
+		inserted, rotations = tree.Put(key, request.Value)
+		return nil
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.OrderedMapMutationResponse{
+		Key:       key,
+		Value:     request.Value,
+		Inserted:  inserted,
+		Rotations: rotations,
+	}
+
+	return response, nil
+}
+
+func (service *OrderedMapServiceImpl) GetFromOwner(id string, owner string, key int64) (web.OrderedMapEntryResponse, error) {
+	response := web.OrderedMapEntryResponse{}
+
+	orderedMap, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return response, err
+	}
+
+	tree, isKnownKind := structure.NewOrderedMap(orderedMap.Kind, orderedMap.Nodes)
+	if !isKnownKind {
+		return response, ErrUnknownKind
+	}
+
+	value, found := tree.Get(key)
+	if !found {
+		return response, ErrNotFound
+	}
+
+	response = web.OrderedMapEntryResponse{
+		Key:   key,
+		Value: value,
+	}
+
+	return response, nil
+}
+
+func (service *OrderedMapServiceImpl) DeleteFromOwner(id string, owner string, key int64) (web.OrderedMapMutationResponse, error) {
+	response := web.OrderedMapMutationResponse{}
+
+	var value string
+	var rotations []domain.Rotation
+	if err := service.update(id, owner, func(tree structure.OrderedMap) error {
+		var deleted bool
+		value, deleted, rotations = tree.Delete(key)
+		if !deleted {
+			return ErrNotFound
+		}
+		return nil
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.OrderedMapMutationResponse{
+		Key:       key,
+		Value:     value,
+		Rotations: rotations,
+	}
+
+	return response, nil
+}
+
+func (service *OrderedMapServiceImpl) EntriesFromOwner(id string, owner string) ([]web.OrderedMapEntryResponse, error) {
+	orderedMap, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, isKnownKind := structure.NewOrderedMap(orderedMap.Kind, orderedMap.Nodes)
+	if !isKnownKind {
+		return nil, ErrUnknownKind
+	}
+
+	response := []web.OrderedMapEntryResponse{}
+	for _, entry := range tree.Entries() {
+		response = append(response, web.OrderedMapEntryResponse{
+			Key:   entry.Key,
+			Value: entry.Value,
+		})
+	}
+
+	return response, nil
+}
+
+func (service *OrderedMapServiceImpl) findFromOwner(id string, owner string) (domain.OrderedMap, error) {
+	orderedMaps, err := service.orderedMapRepository.FindByOwner(context.Background(), owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return domain.OrderedMap{}, ErrNotFound
+		}
+		return domain.OrderedMap{}, err
+	}
+
+	for _, orderedMap := range orderedMaps {
+		if orderedMap.ID == id {
+			return orderedMap, nil
+		}
+	}
+
+	return domain.OrderedMap{}, ErrNotFound
+}
+
+// Apply a change to the latest version of the map
+func (service *OrderedMapServiceImpl) update(id string, owner string, change func(structure.OrderedMap) error) error {
+	return updateVersioned(func() error {
+		orderedMap, err := service.findFromOwner(id, owner)
+		if err != nil {
+			return err
+		}
+
+		tree, isKnownKind := structure.NewOrderedMap(orderedMap.Kind, orderedMap.Nodes)
+		if !isKnownKind {
+			return ErrUnknownKind
+		}
+		if err := change(tree); err != nil {
+			return err
+		}
+		orderedMap.Nodes = tree.Nodes()
+
+		_, err = service.orderedMapRepository.Update(context.Background(), orderedMap)
+		return err
+	})
+}
+
+func newOrderedMapResponse(orderedMap domain.OrderedMap) (web.OrderedMapResponse, error) {
+	tree, isKnownKind := structure.NewOrderedMap(orderedMap.Kind, orderedMap.Nodes)
+	if !isKnownKind {
+		return web.OrderedMapResponse{}, ErrUnknownKind
+	}
+
+	nodes := tree.Nodes()
+	height := func(index int) int {
+		if index < 0 {
+			return 0
+		}
+		return nodes[index].Height
+	}
+
+	nodeResponses := []web.OrderedMapNodeResponse{}
+	for _, node := range nodes {
+		nodeResponse := web.OrderedMapNodeResponse{
+			Key:    node.Key,
+			Value:  node.Value,
+			Left:   node.Left,
+			Right:  node.Right,
+			Height: node.Height,
+			Color:  node.Color,
+		}
+		if orderedMap.Kind == domain.OrderedMapAVL {
+			balanceFactor := height(node.Left) - height(node.Right)
+			nodeResponse.BalanceFactor = &balanceFactor
+		}
+		nodeResponses = append(nodeResponses, nodeResponse)
+	}
+
+	return web.OrderedMapResponse{
+		ID:     orderedMap.ID,
+		Owner:  orderedMap.Owner,
+		Kind:   orderedMap.Kind,
+		Size:   tree.Size(),
+		Height: tree.Height(),
+		Nodes:  nodeResponses,
+	}, nil
+}
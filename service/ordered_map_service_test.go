@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/structure"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// An ordered map repository that lets another update land right before each update of the test
+type memoryOrderedMapRepository struct {
+	repository.OrderedMapRepository
+	orderedMap  domain.OrderedMap
+	interleaved func(*domain.OrderedMap)
+}
+
+func (memory *memoryOrderedMapRepository) FindByOwner(ctx context.Context, owner string) ([]domain.OrderedMap, error) {
+	if memory.orderedMap.Owner != owner {
+		return []domain.OrderedMap{}, nil
+	}
+	return []domain.OrderedMap{memory.orderedMap}, nil
+}
+
+func (memory *memoryOrderedMapRepository) Update(ctx context.Context, orderedMap domain.OrderedMap) (domain.OrderedMap, error) {
+	if memory.interleaved != nil {
+		memory.interleaved(&memory.orderedMap)
+	}
+	if orderedMap.ID != memory.orderedMap.ID || orderedMap.Owner != memory.orderedMap.Owner {
+		return orderedMap, repository.ErrNoData
+	}
+	if orderedMap.Version != memory.orderedMap.Version {
+		return orderedMap, repository.ErrStaleData
+	}
+	orderedMap.Version++
+	memory.orderedMap = orderedMap
+	return orderedMap, nil
+}
+
+func TestOrderedMapConcurrentUpdates(t *testing.T) {
+	for _, kind := range []domain.OrderedMapKind{domain.OrderedMapAVL, domain.OrderedMapRedBlack} {
+		t.Run(string(kind), func(t *testing.T) {
+			orderedMapRepository := &memoryOrderedMapRepository{orderedMap: domain.OrderedMap{ID: "1", Kind: kind, Nodes: []domain.OrderedMapNode{}, Owner: "owner"}}
+			orderedMapService := NewOrderedMapService(orderedMapRepository, nil, validator.New())
+
+			// Another put lands between the read and the update of the first attempt
+			orderedMapRepository.interleaved = func(orderedMap *domain.OrderedMap) {
+				orderedMapRepository.interleaved = nil
+				tree, _ := structure.NewOrderedMap(orderedMap.Kind, orderedMap.Nodes)
+				tree.Put(1, "other")
+				orderedMap.Nodes, orderedMap.Version = tree.Nodes(), orderedMap.Version+1
+			}
+
+			response, err := orderedMapService.PutFromOwner("1", "owner", 2, web.OrderedMapEntryRequest{Value: "mine"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !response.Inserted {
+				t.Fatal("expected the key to be inserted")
+			}
+			entries, err := orderedMapService.EntriesFromOwner("1", "owner")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 || entries[0].Value != "other" || entries[1].Value != "mine" {
+				t.Fatalf("expected both entries to be kept, got %v", entries)
+			}
+
+			// A map that keeps changing is reported as a conflict
+			orderedMapRepository.interleaved = func(orderedMap *domain.OrderedMap) {
+				orderedMap.Version++
+			}
+			if _, err := orderedMapService.DeleteFromOwner("1", "owner", 2); !errors.Is(err, ErrConflict) {
+				t.Fatalf("expected ErrConflict, got %v", err)
+			}
+		})
+	}
+}
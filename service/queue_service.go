@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type QueueService interface {
+	Create(string) (web.QueueResponse, error)
+	FindById(string) (web.QueueResponse, error)
+	FindByIdFromOwner(id string, owner string) (web.QueueResponse, error)
+	FindAll() ([]web.QueueResponse, error)
+	FindAllFromOwner(string) ([]web.QueueResponse, error)
+	Enqueue(string, web.ItemRequest) (web.ItemResponse, error)
+	EnqueueFromOwner(id string, owner string, request web.ItemRequest) (web.ItemResponse, error)
+	Dequeue(string) (web.ItemResponse, error)
+	DequeueFromOwner(id string, owner string) (web.ItemResponse, error)
+	Peek(string) (web.ItemResponse, error)
+	PeekFromOwner(id string, owner string) (web.ItemResponse, error)
+}
+
+type QueueServiceImpl struct {
+	queueRepository repository.QueueRepository
+	userRepository  repository.UserRepository
+	validate        *validator.Validate
+	itemMaxSize     int
+}
+
+func NewQueueService(queueRepository repository.QueueRepository, userRepository repository.UserRepository, validate *validator.Validate, itemMaxSize int) QueueService {
+	service := new(QueueServiceImpl)
+	service.queueRepository = queueRepository
+	service.userRepository = userRepository
+	service.validate = validate
+	service.itemMaxSize = itemMaxSize
+
+	return service
+}
+
+func (service *QueueServiceImpl) Create(id string) (web.QueueResponse, error) {
+	response := web.QueueResponse{}
+
+	user, err := service.userRepository.FindById(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	queue, err := service.queueRepository.Insert(context.Background(), domain.Queue{
+		Items: []domain.Item{},
+		Owner: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateData) {
+			return response, ErrDuplicate
+		}
+		return response, err
+	}
+
+	return newQueueResponse(queue)
+}
+
+func (service *QueueServiceImpl) FindById(id string) (web.QueueResponse, error) {
+	return service.find(id, "")
+}
+
+func (service *QueueServiceImpl) FindByIdFromOwner(id string, owner string) (web.QueueResponse, error) {
+	return service.find(id, owner)
+}
+
+func (service *QueueServiceImpl) FindAll() ([]web.QueueResponse, error) {
+	queues, err := service.queueRepository.FindAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	response := []web.QueueResponse{}
+	for _, queue := range queues {
+		queueResponse, err := newQueueResponse(queue)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, queueResponse)
+	}
+
+	return response, nil
+}
+
+func (service *QueueServiceImpl) FindAllFromOwner(owner string) ([]web.QueueResponse, error) {
+	queues, err := service.queueRepository.FindByOwner(context.Background(), owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	response := []web.QueueResponse{}
+	for _, queue := range queues {
+		queueResponse, err := newQueueResponse(queue)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, queueResponse)
+	}
+
+	return response, nil
+}
+
+func (service *QueueServiceImpl) Enqueue(id string, request web.ItemRequest) (web.ItemResponse, error) {
+	return service.enqueue(id, "", request)
+}
+
+func (service *QueueServiceImpl) EnqueueFromOwner(id string, owner string, request web.ItemRequest) (web.ItemResponse, error) {
+	return service.enqueue(id, owner, request)
+}
+
+func (service *QueueServiceImpl) Dequeue(id string) (web.ItemResponse, error) {
+	return service.dequeue(id, "")
+}
+
+func (service *QueueServiceImpl) DequeueFromOwner(id string, owner string) (web.ItemResponse, error) {
+	return service.dequeue(id, owner)
+}
+
+func (service *QueueServiceImpl) Peek(id string) (web.ItemResponse, error) {
+	return service.peek(id, "")
+}
+
+func (service *QueueServiceImpl) PeekFromOwner(id string, owner string) (web.ItemResponse, error) {
+	return service.peek(id, owner)
+}
+
+// An empty owner matches a queue of any owner
+func (service *QueueServiceImpl) find(id string, owner string) (web.QueueResponse, error) {
+	queue, err := service.queueRepository.FindByIdFromOwner(context.Background(), id, owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return web.QueueResponse{}, ErrNotFound
+		}
+		return web.QueueResponse{}, err
+	}
+
+	return newQueueResponse(queue)
+}
+
+// Items are pushed and popped in a single update so concurrent requests never lose an item.
+// Item indexes keep increasing, the front item holds the lowest index. An empty owner matches any queue.
+func (service *QueueServiceImpl) enqueue(id string, owner string, request web.ItemRequest) (web.ItemResponse, error) {
+	response := web.ItemResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	item, err := newItem(request, service.itemMaxSize)
+	if err != nil {
+		return response, err
+	}
+
+	item, err = service.queueRepository.PushItem(context.Background(), id, owner, item)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	return newItemResponse(item)
+}
+
+func (service *QueueServiceImpl) dequeue(id string, owner string) (web.ItemResponse, error) {
+	response := web.ItemResponse{}
+
+	item, err := service.queueRepository.PopFront(context.Background(), id, owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		} else if errors.Is(err, repository.ErrEmptyData) {
+			return response, ErrEmpty
+		}
+		return response, err
+	}
+
+	return newItemResponse(item)
+}
+
+// Only the front item is read, an empty owner matches a queue of any owner
+func (service *QueueServiceImpl) peek(id string, owner string) (web.ItemResponse, error) {
+	item, err := service.queueRepository.FrontItem(context.Background(), id, owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return web.ItemResponse{}, ErrNotFound
+		} else if errors.Is(err, repository.ErrEmptyData) {
+			return web.ItemResponse{}, ErrEmpty
+		}
+		return web.ItemResponse{}, err
+	}
+
+	return newItemResponse(item)
+}
+
+func newQueueResponse(queue domain.Queue) (web.QueueResponse, error) {
+	items, err := newItemResponses(queue.Items)
+	if err != nil {
+		return web.QueueResponse{}, err
+	}
+
+	return web.QueueResponse{
+		ID:    queue.ID,
+		Owner: queue.Owner,
+		Items: items,
+	}, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type RoleService interface {
+	FindById(string) (web.RoleResponse, error)
+	FindAll() ([]web.RoleResponse, error)
+	Put(web.AuthResponse, string, web.RoleRequest) (web.RoleResponse, error)
+	Delete(string) error
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type RoleServiceImpl struct {
+	roleRepository repository.RoleRepository
+	validate       *validator.Validate
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, validate *validator.Validate) RoleService {
+	roleService := new(RoleServiceImpl)
+	roleService.roleRepository = roleRepository
+	roleService.validate = validate
+
+	return roleService
+}
+
+func (service *RoleServiceImpl) FindById(id string) (web.RoleResponse, error) {
+	role, err := service.roleRepository.FindById(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return web.RoleResponse{}, ErrNotFound
+		}
+		return web.RoleResponse{}, err
+	}
+
+	return newRoleResponse(role), nil
+}
+
+func (service *RoleServiceImpl) FindAll() ([]web.RoleResponse, error) {
+	roles, err := service.roleRepository.FindAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	response := []web.RoleResponse{}
+	for _, role := range roles {
+		response = append(response, newRoleResponse(role))
+	}
+
+	return response, nil
+}
+
+// Create a role or replace its permissions, the admin role cannot be changed.
+// A role can only hold permissions that the caller holds, so that no one can grant more than they have.
+func (service *RoleServiceImpl) Put(authResponse web.AuthResponse, id string, request web.RoleRequest) (web.RoleResponse, error) {
+	if err := service.validate.Struct(request); err != nil {
+		return web.RoleResponse{}, ErrBadRequest
+	}
+	if !roleNamePattern.MatchString(id) || id == domain.RoleAdmin {
+		return web.RoleResponse{}, ErrBadRequest
+	}
+
+	permissions := []domain.Permission{}
+	for _, permission := range request.Permissions {
+		if !knownPermission(permission) {
+			return web.RoleResponse{}, ErrBadRequest
+		}
+		if !authResponse.Can(permission) {
+			return web.RoleResponse{}, ErrForbidden
+		}
+		if !containsPermission(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	role, err := service.roleRepository.Put(context.Background(), domain.Role{ID: id, Permissions: permissions})
+	if err != nil {
+		return web.RoleResponse{}, err
+	}
+
+	return newRoleResponse(role), nil
+}
+
+// Built-in roles cannot be deleted.
+// Users keep the name of a deleted role, it grants nothing unless a role with that name is created again.
+func (service *RoleServiceImpl) Delete(id string) error {
+	if id == domain.RoleAdmin || id == domain.RoleClient {
+		return ErrBadRequest
+	}
+
+	if err := service.roleRepository.Delete(context.Background(), id); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Permissions granted by a set of roles, roles that do not exist grant nothing
+func rolePermissions(ctx context.Context, roleRepository repository.RoleRepository, names []string) ([]domain.Permission, error) {
+	roles, err := roleRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := []domain.Permission{}
+	for _, role := range roles {
+		for _, name := range names {
+			if role.ID != name {
+				continue
+			}
+			for _, permission := range role.Permissions {
+				if !containsPermission(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+func knownPermission(permission domain.Permission) bool {
+	return containsPermission(domain.Permissions, permission)
+}
+
+func containsPermission(permissions []domain.Permission, permission domain.Permission) bool {
+	for _, element := range permissions {
+		if element == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRoleResponse(role domain.Role) web.RoleResponse {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []domain.Permission{}
+	}
+
+	return web.RoleResponse{
+		ID:          role.ID,
+		Permissions: permissions,
+		BuiltIn:     role.BuiltIn,
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type memoryRoleRepository struct {
+	repository.RoleRepository
+	roles []domain.Role
+}
+
+func (memory *memoryRoleRepository) FindAll(ctx context.Context) ([]domain.Role, error) {
+	return memory.roles, nil
+}
+
+func (memory *memoryRoleRepository) FindById(ctx context.Context, id string) (domain.Role, error) {
+	for _, role := range memory.roles {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+	return domain.Role{}, repository.ErrNoData
+}
+
+func (memory *memoryRoleRepository) Put(ctx context.Context, role domain.Role) (domain.Role, error) {
+	memory.roles = append(memory.roles, role)
+	return role, nil
+}
+
+func TestRolePermissions(t *testing.T) {
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{
+		{ID: domain.RoleClient, Permissions: []domain.Permission{}},
+		{ID: "support", Permissions: []domain.Permission{domain.PermissionStacksReadAny, domain.PermissionUsersRevoke}},
+		{ID: "editor", Permissions: []domain.Permission{domain.PermissionStacksReadAny, domain.PermissionStacksWriteAny}},
+	}}
+
+	permissions, err := rolePermissions(context.Background(), roleRepository, []string{"support", "editor", "deleted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []domain.Permission{domain.PermissionStacksReadAny, domain.PermissionUsersRevoke, domain.PermissionStacksWriteAny}
+	if len(permissions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, permissions)
+	}
+	for _, permission := range expected {
+		if !containsPermission(permissions, permission) {
+			t.Fatalf("expected %v, got %v", expected, permissions)
+		}
+	}
+
+	permissions, err = rolePermissions(context.Background(), roleRepository, []string{domain.RoleClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 0 {
+		t.Fatalf("expected the client role to grant nothing, got %v", permissions)
+	}
+}
+
+func TestRolePermissionsCannotBeEscalated(t *testing.T) {
+	roleRepository := &memoryRoleRepository{roles: []domain.Role{
+		{ID: domain.RoleAdmin, Permissions: domain.Permissions},
+		{ID: domain.RoleClient, Permissions: []domain.Permission{}},
+		{ID: "support", Permissions: []domain.Permission{domain.PermissionUsersRevoke}},
+	}}
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Roles: []string{"manager"}},
+	}}
+	roleService := NewRoleService(roleRepository, validator.New())
+	userService := NewUserService(userRepository, roleRepository, nil, validator.New(), nil)
+
+	manager := web.AuthResponse{
+		ID:          "1",
+		Roles:       []string{"manager"},
+		Permissions: []domain.Permission{domain.PermissionRolesManage, domain.PermissionRolesAssign, domain.PermissionUsersRevoke},
+	}
+
+	if _, err := roleService.Put(manager, "owner", web.RoleRequest{Permissions: []domain.Permission{domain.PermissionUsersDelete}}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected a role with a permission the caller lacks to be refused, got %v", err)
+	}
+	if _, err := roleService.Put(manager, "helper", web.RoleRequest{Permissions: []domain.Permission{domain.PermissionUsersRevoke}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := userService.SetRoles(manager, "1", web.UserRolesRequest{Roles: []string{domain.RoleAdmin}}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected the admin role to be refused, got %v", err)
+	}
+	if userRepository.users["1"].Roles[0] != "manager" {
+		t.Fatalf("expected the roles to be kept, got %v", userRepository.users["1"].Roles)
+	}
+	if err := userService.SetRoles(manager, "1", web.UserRolesRequest{Roles: []string{"support", domain.RoleClient}}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,6 +3,12 @@ package service
 import "errors"
 
 var ErrBadRequest = errors.New("bad request")
+var ErrConflict = errors.New("conflict")
 var ErrDuplicate = errors.New("duplicate")
+var ErrEmpty = errors.New("empty")
+var ErrForbidden = errors.New("forbidden")
+var ErrFull = errors.New("full")
 var ErrNotFound = errors.New("not found")
+var ErrTooManyRequests = errors.New("too many requests")
 var ErrUnauthorized = errors.New("unauthorized")
+var ErrUnknownKind = errors.New("unknown kind")
@@ -2,44 +2,103 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"godas/model/domain"
 	"godas/model/web"
 	"godas/repository"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 type StackService interface {
-	Create(string) (web.StackResponse, error)
+	Create(string, web.StackCreateRequest) (web.StackResponse, error)
 	FindById(string) (web.StackResponse, error)
 	FindByIdFromOwner(id string, owner string) (web.StackResponse, error)
-	FindAll() ([]web.StackResponse, error)
-	FindAllFromOwner(string) ([]web.StackResponse, error)
-	Push(string, web.ItemRequest) (web.ItemResponse, error)
-	PushFromOwner(id string, owner string, request web.ItemRequest) (web.ItemResponse, error)
+	FindAll(web.StackFilterRequest) (web.StackPageResponse, error)
+	FindAllFromOwner(owner string, request web.StackFilterRequest) (web.StackPageResponse, error)
+	Items(string, web.StackItemsRequest) (web.StackItemsResponse, error)
+	ItemsFromOwner(id string, owner string, request web.StackItemsRequest) (web.StackItemsResponse, error)
+	Push(context.Context, string, web.ItemRequest) (web.ItemResponse, error)
+	PushFromOwner(ctx context.Context, id string, owner string, request web.ItemRequest) (web.ItemResponse, error)
 	Pop(string) (web.ItemResponse, error)
 	PopFromOwner(id string, owner string) (web.ItemResponse, error)
+	PushMany(context.Context, string, []web.ItemRequest) ([]web.ItemResponse, error)
+	PushManyFromOwner(ctx context.Context, id string, owner string, requests []web.ItemRequest) ([]web.ItemResponse, error)
+	PopMany(string, int) ([]web.ItemResponse, error)
+	PopManyFromOwner(id string, owner string, count int) ([]web.ItemResponse, error)
+	Top(string) (web.ItemResponse, error)
+	TopFromOwner(id string, owner string) (web.ItemResponse, error)
+	Size(string) (web.StackSizeResponse, error)
+	SizeFromOwner(id string, owner string) (web.StackSizeResponse, error)
+	Clear(string) error
+	ClearFromOwner(id string, owner string) error
+	Delete(string) error
+	DeleteFromOwner(id string, owner string) error
+	SetSchema(string, web.StackSchemaRequest) (web.StackResponse, error)
+	SetSchemaFromOwner(id string, owner string, request web.StackSchemaRequest) (web.StackResponse, error)
 }
 
+// Largest number of items pushed or popped in a single request
+const MaxStackBatchSize = 1000
+
+// How long a push to a full stack with the block policy waits when the stack sets no timeout
+const DefaultStackBlockTimeout = 5 * time.Second
+
+// A blocking push polls the stack after stackBlockPollInterval, then less and less often
+const (
+	stackBlockPollInterval    = 50 * time.Millisecond
+	stackBlockMaxPollInterval = time.Second
+)
+
+// Number of stacks in a page and of items in a window when the request sets no limit
+const (
+	DefaultStackPageSize   = 20
+	DefaultStackItemsLimit = 100
+)
+
 type StackServiceImpl struct {
 	stackRepository repository.StackRepository
 	userRepository  repository.UserRepository
 	validate        *validator.Validate
+	itemMaxSize     int
 }
 
-func NewStackService(stackRepository repository.StackRepository, userRepository repository.UserRepository, validate *validator.Validate) StackService {
+func NewStackService(stackRepository repository.StackRepository, userRepository repository.UserRepository, validate *validator.Validate, itemMaxSize int) StackService {
 	service := new(StackServiceImpl)
 	service.stackRepository = stackRepository
 	service.userRepository = userRepository
 	service.validate = validate
+	service.itemMaxSize = itemMaxSize
 
 	return service
 }
 
-func (service *StackServiceImpl) Create(id string) (web.StackResponse, error) {
+func (service *StackServiceImpl) Create(id string, request web.StackCreateRequest) (web.StackResponse, error) {
 	response := web.StackResponse{}
 
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	schema, err := parseSchema(request.Schema)
+	if err != nil {
+		return response, err
+	}
+
+	// An overflow policy only applies to a bounded stack, which rejects pushes by default
+	if request.MaxSize == 0 && (request.OverflowPolicy != "" || request.BlockTimeout > 0) {
+		return response, ErrBadRequest
+	}
+	if request.BlockTimeout > 0 && request.OverflowPolicy != domain.OverflowPolicyBlock {
+		return response, ErrBadRequest
+	}
+	if request.MaxSize > 0 && request.OverflowPolicy == "" {
+		request.OverflowPolicy = domain.OverflowPolicyReject
+	}
+
 	user, err := service.userRepository.FindById(context.Background(), id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
@@ -49,8 +108,15 @@ func (service *StackServiceImpl) Create(id string) (web.StackResponse, error) {
 	}
 
 	stack, err := service.stackRepository.Insert(context.Background(), domain.Stack{
-		Items: []domain.Item{},
-		Owner: user.ID,
+		Name:           request.Name,
+		Description:    request.Description,
+		Tags:           request.Tags,
+		Items:          []domain.Item{},
+		Owner:          user.ID,
+		Schema:         schema,
+		MaxSize:        request.MaxSize,
+		OverflowPolicy: request.OverflowPolicy,
+		BlockTimeout:   request.BlockTimeout,
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateData) {
@@ -59,11 +125,7 @@ func (service *StackServiceImpl) Create(id string) (web.StackResponse, error) {
 		return response, err
 	}
 
-	return web.StackResponse{
-		ID:    stack.ID,
-		Owner: stack.Owner,
-		Items: stack.Items,
-	}, nil
+	return newStackResponse(stack)
 }
 
 func (service *StackServiceImpl) FindById(id string) (web.StackResponse, error) {
@@ -77,13 +139,7 @@ func (service *StackServiceImpl) FindById(id string) (web.StackResponse, error)
 		return response, err
 	}
 
-	response = web.StackResponse{
-		ID:    stack.ID,
-		Owner: stack.Owner,
-		Items: stack.Items,
-	}
-
-	return response, nil
+	return newStackResponse(stack)
 }
 
 func (service *StackServiceImpl) FindByIdFromOwner(id string, owner string) (web.StackResponse, error) {
@@ -103,160 +159,310 @@ func (service *StackServiceImpl) FindByIdFromOwner(id string, owner string) (web
 
 	for _, stack := range stacks {
 		if stack.ID == id {
-			return web.StackResponse{
-				ID:    stack.ID,
-				Owner: stack.Owner,
-				Items: stack.Items,
-			}, nil
+			return newStackResponse(stack)
 		}
 	}
 
 	return response, ErrNotFound
 }
 
-func (service *StackServiceImpl) FindAll() ([]web.StackResponse, error) {
-	stacks, err := service.stackRepository.FindAll(context.Background())
-	if err != nil {
-		return nil, err
-	}
+func (service *StackServiceImpl) FindAll(request web.StackFilterRequest) (web.StackPageResponse, error) {
+	return service.find("", request)
+}
 
-	response := []web.StackResponse{}
-	for _, stack := range stacks {
-		response = append(response, web.StackResponse{
-			ID:    stack.ID,
-			Owner: stack.Owner,
-			Items: stack.Items,
-		})
-	}
+func (service *StackServiceImpl) FindAllFromOwner(owner string, request web.StackFilterRequest) (web.StackPageResponse, error) {
+	return service.find(owner, request)
+}
 
-	return response, nil
+func (service *StackServiceImpl) Items(id string, request web.StackItemsRequest) (web.StackItemsResponse, error) {
+	return service.items(id, "", request)
 }
 
-func (service *StackServiceImpl) FindAllFromOwner(owner string) ([]web.StackResponse, error) {
-	stacks, err := service.stackRepository.FindByOwner(context.Background(), owner)
+func (service *StackServiceImpl) ItemsFromOwner(id string, owner string, request web.StackItemsRequest) (web.StackItemsResponse, error) {
+	return service.items(id, owner, request)
+}
+
+func (service *StackServiceImpl) Push(ctx context.Context, id string, request web.ItemRequest) (web.ItemResponse, error) {
+	return firstItem(service.push(ctx, id, "", []web.ItemRequest{request}))
+}
+
+func (service *StackServiceImpl) PushFromOwner(ctx context.Context, id string, owner string, request web.ItemRequest) (web.ItemResponse, error) {
+	return firstItem(service.push(ctx, id, owner, []web.ItemRequest{request}))
+}
+
+func (service *StackServiceImpl) PushMany(ctx context.Context, id string, requests []web.ItemRequest) ([]web.ItemResponse, error) {
+	return service.push(ctx, id, "", requests)
+}
+
+func (service *StackServiceImpl) PushManyFromOwner(ctx context.Context, id string, owner string, requests []web.ItemRequest) ([]web.ItemResponse, error) {
+	return service.push(ctx, id, owner, requests)
+}
+
+func (service *StackServiceImpl) Pop(id string) (web.ItemResponse, error) {
+	return firstItem(service.pop(id, "", 1))
+}
+
+func (service *StackServiceImpl) PopFromOwner(id string, owner string) (web.ItemResponse, error) {
+	return firstItem(service.pop(id, owner, 1))
+}
+
+func (service *StackServiceImpl) PopMany(id string, count int) ([]web.ItemResponse, error) {
+	return service.pop(id, "", count)
+}
+
+func (service *StackServiceImpl) PopManyFromOwner(id string, owner string, count int) ([]web.ItemResponse, error) {
+	return service.pop(id, owner, count)
+}
+
+func (service *StackServiceImpl) Top(id string) (web.ItemResponse, error) {
+	return service.top(id, "")
+}
+
+func (service *StackServiceImpl) TopFromOwner(id string, owner string) (web.ItemResponse, error) {
+	return service.top(id, owner)
+}
+
+func (service *StackServiceImpl) Size(id string) (web.StackSizeResponse, error) {
+	return service.size(id, "")
+}
+
+func (service *StackServiceImpl) SizeFromOwner(id string, owner string) (web.StackSizeResponse, error) {
+	return service.size(id, owner)
+}
+
+func (service *StackServiceImpl) Clear(id string) error {
+	return service.clear(id, "")
+}
+
+func (service *StackServiceImpl) ClearFromOwner(id string, owner string) error {
+	return service.clear(id, owner)
+}
+
+func (service *StackServiceImpl) Delete(id string) error {
+	return service.delete(id, "")
+}
+
+func (service *StackServiceImpl) DeleteFromOwner(id string, owner string) error {
+	return service.delete(id, owner)
+}
+
+func (service *StackServiceImpl) SetSchema(id string, request web.StackSchemaRequest) (web.StackResponse, error) {
+	return service.setSchema(id, "", request)
+}
+
+func (service *StackServiceImpl) SetSchemaFromOwner(id string, owner string, request web.StackSchemaRequest) (web.StackResponse, error) {
+	return service.setSchema(id, owner, request)
+}
+
+// An empty owner matches stacks of any owner
+func (service *StackServiceImpl) find(owner string, request web.StackFilterRequest) (web.StackPageResponse, error) {
+	response := web.StackPageResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = DefaultStackPageSize
+	}
+
+	// One more stack than requested tells whether there is a next page
+	summaries, err := service.stackRepository.FindSummaries(context.Background(), repository.StackFilter{
+		Owner:      owner,
+		Tag:        request.Tag,
+		NamePrefix: request.Name,
+		After:      request.Cursor,
+		Limit:      limit + 1,
+	})
 	if err != nil {
-		if errors.Is(err, repository.ErrNoData) {
-			return nil, ErrNotFound
-		}
-		return nil, err
+		return response, err
 	}
 
-	response := []web.StackResponse{}
-	for _, stack := range stacks {
-		response = append(response, web.StackResponse{
-			ID:    stack.ID,
-			Owner: stack.Owner,
-			Items: stack.Items,
-		})
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+		response.NextCursor = summaries[limit-1].ID
+	}
+
+	response.Stacks = []web.StackSummaryResponse{}
+	for _, summary := range summaries {
+		response.Stacks = append(response.Stacks, newStackSummaryResponse(summary))
 	}
 
 	return response, nil
 }
 
-func (service *StackServiceImpl) Push(id string, request web.ItemRequest) (web.ItemResponse, error) {
-	response := web.ItemResponse{}
+// An empty owner allows reading items of a stack of any owner
+func (service *StackServiceImpl) items(id string, owner string, request web.StackItemsRequest) (web.StackItemsResponse, error) {
+	response := web.StackItemsResponse{}
 
 	if err := service.validate.Struct(request); err != nil {
 		return response, ErrBadRequest
 	}
 
-	stack, err := service.stackRepository.FindById(context.Background(), id)
+	window := repository.ItemWindow{
+		FromTop: request.From != "bottom",
+		Offset:  request.Offset,
+		Limit:   request.Limit,
+	}
+	if window.Limit == 0 {
+		window.Limit = DefaultStackItemsLimit
+	}
+
+	items, size, err := service.stackRepository.FindItems(context.Background(), id, owner, window)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
 		}
 		return response, err
 	}
-	item := domain.Item{
-		Index: uint64(len(stack.Items)),
-		Name:  request.Name,
-	}
-	stack.Items = append(stack.Items, item)
 
-	stack, err = service.stackRepository.Update(context.Background(), stack)
+	itemResponses, err := newItemResponses(items)
 	if err != nil {
-		if errors.Is(err, repository.ErrNoData) {
-			return response, ErrNotFound
-		}
 		return response, err
 	}
 
-	response = web.ItemResponse{
-		Index: item.Index,
-		Name:  item.Name,
+	response = web.StackItemsResponse{
+		Size:  size,
+		Items: itemResponses,
 	}
 
 	return response, nil
 }
 
-func (service *StackServiceImpl) PushFromOwner(id string, owner string, request web.ItemRequest) (web.ItemResponse, error) {
-	response := web.ItemResponse{}
-
-	if err := service.validate.Struct(request); err != nil {
-		return response, ErrBadRequest
+// An empty owner allows pushing to a stack of any owner. A push waiting for room in a blocking stack
+// stops when ctx is done.
+func (service *StackServiceImpl) push(ctx context.Context, id string, owner string, requests []web.ItemRequest) ([]web.ItemResponse, error) {
+	if len(requests) < 1 || len(requests) > MaxStackBatchSize {
+		return nil, ErrBadRequest
 	}
 
-	stacks, err := service.stackRepository.FindByOwner(context.Background(), owner)
+	stack, err := service.stackRepository.FindSettings(ctx, id, owner)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
-			return response, ErrNotFound
+			return nil, ErrNotFound
 		}
-		return response, err
+		return nil, err
 	}
-	if len(stacks) < 1 {
-		return response, ErrNotFound
+
+	// A batch larger than a stack that does not evict can never fit
+	if stack.MaxSize > 0 && stack.OverflowPolicy != domain.OverflowPolicyDropOldest && uint64(len(requests)) > stack.MaxSize {
+		return nil, ErrFull
 	}
 
-	stack := domain.Stack{}
-	found := false
-	for _, s := range stacks {
-		if s.ID == id {
-			stack = s
-			found = true
-			break
+	// The schema is compiled once and shared by every item of the batch
+	var compiledSchema *jsonschema.Schema
+	if stack.Schema != "" {
+		if compiledSchema, err = compileSchema(stack.Schema); err != nil {
+			return nil, err
 		}
 	}
-	if !found {
-		return response, ErrNotFound
-	}
-	item := domain.Item{
-		Index: uint64(len(stack.Items)),
-		Name:  request.Name,
+
+	items := []domain.Item{}
+	for _, request := range requests {
+		if err := service.validate.Struct(request); err != nil {
+			return nil, ErrBadRequest
+		}
+		item, err := newItem(request, service.itemMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		if compiledSchema != nil {
+			if err := validateItemValue(compiledSchema, request.Value); err != nil {
+				return nil, ErrBadRequest
+			}
+		}
+		items = append(items, item)
 	}
-	stack.Items = append(stack.Items, item)
 
-	stack, err = service.stackRepository.Update(context.Background(), stack)
+	pushed, err := service.stackRepository.PushItems(ctx, id, owner, items)
+	if errors.Is(err, repository.ErrFullData) && stack.OverflowPolicy == domain.OverflowPolicyBlock {
+		pushed, err = service.pushBlocking(ctx, stack, owner, items)
+	}
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
-			return response, ErrNotFound
+			return nil, ErrNotFound
+		} else if errors.Is(err, repository.ErrFullData) {
+			return nil, ErrFull
+		}
+		return nil, err
+	}
+
+	return newItemResponses(pushed)
+}
+
+// Poll a full blocking stack until the batch fits, the timeout of the stack is reached or ctx is done.
+// The interval doubles after every poll so that a long wait only takes a few round trips.
+func (service *StackServiceImpl) pushBlocking(ctx context.Context, stack domain.Stack, owner string, items []domain.Item) ([]domain.Item, error) {
+	blockTimeout := DefaultStackBlockTimeout
+	if stack.BlockTimeout > 0 {
+		blockTimeout = time.Duration(stack.BlockTimeout) * time.Millisecond
+	}
+	timeout := time.NewTimer(blockTimeout)
+	defer timeout.Stop()
+
+	interval := stackBlockPollInterval
+	for {
+		poll := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			return nil, ctx.Err()
+		case <-timeout.C:
+			poll.Stop()
+			return nil, repository.ErrFullData
+		case <-poll.C:
+		}
+
+		pushed, err := service.stackRepository.PushItems(ctx, stack.ID, owner, items)
+		if !errors.Is(err, repository.ErrFullData) {
+			return pushed, err
+		}
+		if interval *= 2; interval > stackBlockMaxPollInterval {
+			interval = stackBlockMaxPollInterval
 		}
-		return response, err
 	}
+}
 
-	response = web.ItemResponse{
-		Index: item.Index,
-		Name:  item.Name,
+// An empty owner allows popping from a stack of any owner
+func (service *StackServiceImpl) pop(id string, owner string, count int) ([]web.ItemResponse, error) {
+	if count < 1 || count > MaxStackBatchSize {
+		return nil, ErrBadRequest
 	}
 
-	return response, nil
+	items, err := service.stackRepository.PopItems(context.Background(), id, owner, count)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil, ErrNotFound
+		} else if errors.Is(err, repository.ErrEmptyData) {
+			return nil, ErrEmpty
+		}
+		return nil, err
+	}
+
+	return newItemResponses(items)
 }
 
-func (service *StackServiceImpl) Pop(id string) (web.ItemResponse, error) {
+func (service *StackServiceImpl) top(id string, owner string) (web.ItemResponse, error) {
 	response := web.ItemResponse{}
 
-	stack, err := service.stackRepository.FindById(context.Background(), id)
+	item, err := service.stackRepository.TopItem(context.Background(), id, owner)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
+		} else if errors.Is(err, repository.ErrEmptyData) {
+			return response, ErrEmpty
 		}
 		return response, err
 	}
 
-	pos := len(stack.Items) - 1
-	item := stack.Items[pos]
-	stack.Items = stack.Items[0:pos]
+	return newItemResponse(item)
+}
+
+func (service *StackServiceImpl) size(id string, owner string) (web.StackSizeResponse, error) {
+	response := web.StackSizeResponse{}
 
-	stack, err = service.stackRepository.Update(context.Background(), stack)
+	size, err := service.stackRepository.Size(context.Background(), id, owner)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
@@ -264,46 +470,45 @@ func (service *StackServiceImpl) Pop(id string) (web.ItemResponse, error) {
 		return response, err
 	}
 
-	response = web.ItemResponse{
-		Index: item.Index,
-		Name:  item.Name,
+	response = web.StackSizeResponse{
+		Size: size,
 	}
 
 	return response, nil
 }
 
-func (service *StackServiceImpl) PopFromOwner(id string, owner string) (web.ItemResponse, error) {
-	response := web.ItemResponse{}
-
-	stacks, err := service.stackRepository.FindByOwner(context.Background(), owner)
-	if err != nil {
+func (service *StackServiceImpl) clear(id string, owner string) error {
+	if err := service.stackRepository.Clear(context.Background(), id, owner); err != nil {
 		if errors.Is(err, repository.ErrNoData) {
-			return response, ErrNotFound
+			return ErrNotFound
 		}
-		return response, err
-	}
-	if len(stacks) < 1 {
-		return response, ErrNotFound
+		return err
 	}
 
-	stack := domain.Stack{}
-	found := false
-	for _, s := range stacks {
-		if s.ID == id {
-			stack = s
-			found = true
-			break
+	return nil
+}
+
+func (service *StackServiceImpl) delete(id string, owner string) error {
+	if err := service.stackRepository.Delete(context.Background(), id, owner); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
 		}
-	}
-	if !found {
-		return response, ErrNotFound
+		return err
 	}
 
-	pos := len(stack.Items) - 1
-	item := stack.Items[pos]
-	stack.Items = stack.Items[0:pos]
+	return nil
+}
+
+// An empty owner allows changing the schema of a stack of any owner, an empty schema removes it
+func (service *StackServiceImpl) setSchema(id string, owner string, request web.StackSchemaRequest) (web.StackResponse, error) {
+	response := web.StackResponse{}
 
-	stack, err = service.stackRepository.Update(context.Background(), stack)
+	schema, err := parseSchema(request.Schema)
+	if err != nil {
+		return response, err
+	}
+
+	stack, err := service.stackRepository.SetSchema(context.Background(), id, owner, schema)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
@@ -311,10 +516,79 @@ func (service *StackServiceImpl) PopFromOwner(id string, owner string) (web.Item
 		return response, err
 	}
 
-	response = web.ItemResponse{
-		Index: item.Index,
-		Name:  item.Name,
+	return newStackResponse(stack)
+}
+
+// Check that a raw schema compiles, null or a missing schema means no schema
+func parseSchema(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	if _, err := compileSchema(string(raw)); err != nil {
+		return "", ErrBadRequest
+	}
+
+	return string(raw), nil
+}
+
+func newStackResponse(stack domain.Stack) (web.StackResponse, error) {
+	items, err := newItemResponses(stack.Items)
+	if err != nil {
+		return web.StackResponse{}, err
+	}
+
+	response := web.StackResponse{
+		ID:             stack.ID,
+		Name:           stack.Name,
+		Description:    stack.Description,
+		Tags:           stack.Tags,
+		Owner:          stack.Owner,
+		MaxSize:        stack.MaxSize,
+		OverflowPolicy: stack.OverflowPolicy,
+		BlockTimeout:   stack.BlockTimeout,
+		Items:          items,
+		CreatedAt:      stack.CreatedAt,
+		UpdatedAt:      stack.UpdatedAt,
+	}
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+	if stack.Schema != "" {
+		response.Schema = json.RawMessage(stack.Schema)
 	}
 
 	return response, nil
 }
+
+func newStackSummaryResponse(summary domain.StackSummary) web.StackSummaryResponse {
+	response := web.StackSummaryResponse{
+		ID:             summary.ID,
+		Name:           summary.Name,
+		Description:    summary.Description,
+		Tags:           summary.Tags,
+		Owner:          summary.Owner,
+		MaxSize:        summary.MaxSize,
+		OverflowPolicy: summary.OverflowPolicy,
+		BlockTimeout:   summary.BlockTimeout,
+		Size:           summary.Size,
+		CreatedAt:      summary.CreatedAt,
+		UpdatedAt:      summary.UpdatedAt,
+	}
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+	if summary.Schema != "" {
+		response.Schema = json.RawMessage(summary.Schema)
+	}
+
+	return response
+}
+
+func firstItem(responses []web.ItemResponse, err error) (web.ItemResponse, error) {
+	if err != nil {
+		return web.ItemResponse{}, err
+	}
+
+	return responses[0], nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// A stack repository that is full for the first pushes of the test
+type memoryStackRepository struct {
+	repository.StackRepository
+	stack     domain.Stack
+	fullPolls int
+	pushes    int
+}
+
+func (memory *memoryStackRepository) FindSettings(ctx context.Context, id string, owner string) (domain.Stack, error) {
+	if id != memory.stack.ID || (owner != "" && owner != memory.stack.Owner) {
+		return domain.Stack{}, repository.ErrNoData
+	}
+	return memory.stack, nil
+}
+
+func (memory *memoryStackRepository) PushItems(ctx context.Context, id string, owner string, items []domain.Item) ([]domain.Item, error) {
+	memory.pushes++
+	if memory.pushes <= memory.fullPolls {
+		return nil, repository.ErrFullData
+	}
+	return items, nil
+}
+
+func TestStackBlockPolicy(t *testing.T) {
+	newStackService := func(blockTimeout uint64, fullPolls int) (StackService, *memoryStackRepository) {
+		stackRepository := &memoryStackRepository{
+			stack:     domain.Stack{ID: "1", Owner: "owner", MaxSize: 1, OverflowPolicy: domain.OverflowPolicyBlock, BlockTimeout: blockTimeout},
+			fullPolls: fullPolls,
+		}
+		return NewStackService(stackRepository, nil, validator.New(), 1024), stackRepository
+	}
+	request := web.ItemRequest{Name: "a"}
+
+	// Room is made while the push waits
+	stackService, stackRepository := newStackService(5000, 3)
+	response, err := stackService.PushFromOwner(context.Background(), "1", "owner", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Name != "a" || stackRepository.pushes != 4 {
+		t.Fatalf("expected the item to be pushed on the fourth try, got %v after %d", response, stackRepository.pushes)
+	}
+
+	// The wait polls less and less often
+	stackService, stackRepository = newStackService(1000, math.MaxInt)
+	started := time.Now()
+	if _, err := stackService.PushFromOwner(context.Background(), "1", "owner", request); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull after the timeout, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second || elapsed > 1500*time.Millisecond {
+		t.Fatalf("expected the push to wait for the timeout, waited %v", elapsed)
+	}
+	if stackRepository.pushes > 6 {
+		t.Fatalf("expected a few polls, got %d", stackRepository.pushes)
+	}
+
+	// A request that goes away stops waiting
+	stackService, _ = newStackService(30000, math.MaxInt)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started = time.Now()
+	if _, err := stackService.PushFromOwner(ctx, "1", "owner", request); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the push to stop with the request, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected the push to stop with the request, waited %v", elapsed)
+	}
+}
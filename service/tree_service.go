@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/structure"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type TreeService interface {
+	Create(string) (web.TreeResponse, error)
+	FindByIdFromOwner(id string, owner string) (web.TreeResponse, error)
+	FindAllFromOwner(string) ([]web.TreeResponse, error)
+	InsertFromOwner(id string, owner string, request web.TreeNodeRequest) (web.TreeNodeResponse, error)
+	DeleteFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error)
+	ContainsFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error)
+	MinFromOwner(id string, owner string) (web.TreeNodeResponse, error)
+	MaxFromOwner(id string, owner string) (web.TreeNodeResponse, error)
+	SuccessorFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error)
+	PredecessorFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error)
+	TraverseFromOwner(id string, owner string, order domain.TraversalOrder) (web.TraversalResponse, error)
+}
+
+type TreeServiceImpl struct {
+	treeRepository repository.TreeRepository
+	userRepository repository.UserRepository
+	validate       *validator.Validate
+}
+
+func NewTreeService(treeRepository repository.TreeRepository, userRepository repository.UserRepository, validate *validator.Validate) TreeService {
+	service := new(TreeServiceImpl)
+	service.treeRepository = treeRepository
+	service.userRepository = userRepository
+	service.validate = validate
+
+	return service
+}
+
+func (service *TreeServiceImpl) Create(id string) (web.TreeResponse, error) {
+	response := web.TreeResponse{}
+
+	user, err := service.userRepository.FindById(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	tree, err := service.treeRepository.Insert(context.Background(), domain.Tree{
+		Nodes: []domain.TreeNode{},
+		Owner: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateData) {
+			return response, ErrDuplicate
+		}
+		return response, err
+	}
+
+	return newTreeResponse(tree), nil
+}
+
+func (service *TreeServiceImpl) FindByIdFromOwner(id string, owner string) (web.TreeResponse, error) {
+	tree, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return web.TreeResponse{}, err
+	}
+
+	return newTreeResponse(tree), nil
+}
+
+func (service *TreeServiceImpl) FindAllFromOwner(owner string) ([]web.TreeResponse, error) {
+	trees, err := service.treeRepository.FindByOwner(context.Background(), owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	response := []web.TreeResponse{}
+	for _, tree := range trees {
+		response = append(response, newTreeResponse(tree))
+	}
+
+	return response, nil
+}
+
+func (service *TreeServiceImpl) InsertFromOwner(id string, owner string, request web.TreeNodeRequest) (web.TreeNodeResponse, error) {
+	response := web.TreeNodeResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	if err := service.update(id, owner, func(bst *structure.BST) error {
+		if !bst.Insert(*request.Key) {
+			return ErrDuplicate
+		}
+		return nil
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.TreeNodeResponse{
+		Key: *request.Key,
+	}
+
+	return response, nil
+}
+
+func (service *TreeServiceImpl) DeleteFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error) {
+	response := web.TreeNodeResponse{}
+
+	if err := service.update(id, owner, func(bst *structure.BST) error {
+		if !bst.Delete(key) {
+			return ErrNotFound
+		}
+		return nil
+	}); err != nil {
+		return response, err
+	}
+
+	response = web.TreeNodeResponse{
+		Key: key,
+	}
+
+	return response, nil
+}
+
+func (service *TreeServiceImpl) ContainsFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error) {
+	return service.query(id, owner, func(bst *structure.BST) (int64, bool) {
+		return key, bst.Contains(key)
+	})
+}
+
+func (service *TreeServiceImpl) MinFromOwner(id string, owner string) (web.TreeNodeResponse, error) {
+	return service.query(id, owner, func(bst *structure.BST) (int64, bool) {
+		return bst.Min()
+	})
+}
+
+func (service *TreeServiceImpl) MaxFromOwner(id string, owner string) (web.TreeNodeResponse, error) {
+	return service.query(id, owner, func(bst *structure.BST) (int64, bool) {
+		return bst.Max()
+	})
+}
+
+func (service *TreeServiceImpl) SuccessorFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error) {
+	return service.query(id, owner, func(bst *structure.BST) (int64, bool) {
+		return bst.Successor(key)
+	})
+}
+
+func (service *TreeServiceImpl) PredecessorFromOwner(id string, owner string, key int64) (web.TreeNodeResponse, error) {
+	return service.query(id, owner, func(bst *structure.BST) (int64, bool) {
+		return bst.Predecessor(key)
+	})
+}
+
+func (service *TreeServiceImpl) TraverseFromOwner(id string, owner string, order domain.TraversalOrder) (web.TraversalResponse, error) {
+	response := web.TraversalResponse{}
+
+	tree, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return response, err
+	}
+
+	keys, ok := structure.NewBST(tree.Nodes).Traverse(order)
+	if !ok {
+		return response, ErrBadRequest
+	}
+
+	response = web.TraversalResponse{
+		Order: order,
+		Keys:  keys,
+	}
+
+	return response, nil
+}
+
+// Apply a change to the latest version of the tree
+func (service *TreeServiceImpl) update(id string, owner string, change func(*structure.BST) error) error {
+	return updateVersioned(func() error {
+		tree, err := service.findFromOwner(id, owner)
+		if err != nil {
+			return err
+		}
+
+		bst := structure.NewBST(tree.Nodes)
+		if err := change(bst); err != nil {
+			return err
+		}
+		tree.Nodes = bst.Nodes()
+
+		_, err = service.treeRepository.Update(context.Background(), tree)
+		return err
+	})
+}
+
+// Run a read-only lookup against the tree, a missing key is reported as ErrNotFound
+func (service *TreeServiceImpl) query(id string, owner string, lookup func(*structure.BST) (int64, bool)) (web.TreeNodeResponse, error) {
+	response := web.TreeNodeResponse{}
+
+	tree, err := service.findFromOwner(id, owner)
+	if err != nil {
+		return response, err
+	}
+
+	key, found := lookup(structure.NewBST(tree.Nodes))
+	if !found {
+		return response, ErrNotFound
+	}
+
+	response = web.TreeNodeResponse{
+		Key: key,
+	}
+
+	return response, nil
+}
+
+func (service *TreeServiceImpl) findFromOwner(id string, owner string) (domain.Tree, error) {
+	trees, err := service.treeRepository.FindByOwner(context.Background(), owner)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return domain.Tree{}, ErrNotFound
+		}
+		return domain.Tree{}, err
+	}
+
+	for _, tree := range trees {
+		if tree.ID == id {
+			return tree, nil
+		}
+	}
+
+	return domain.Tree{}, ErrNotFound
+}
+
+func newTreeResponse(tree domain.Tree) web.TreeResponse {
+	bst := structure.NewBST(tree.Nodes)
+
+	return web.TreeResponse{
+		ID:     tree.ID,
+		Owner:  tree.Owner,
+		Size:   bst.Size(),
+		Height: bst.Height(),
+		Nodes:  tree.Nodes,
+	}
+}
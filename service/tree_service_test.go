@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/repository"
+	"godas/structure"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// A tree repository that lets another update land right before each update of the test
+type memoryTreeRepository struct {
+	repository.TreeRepository
+	tree        domain.Tree
+	interleaved func(*domain.Tree)
+}
+
+func (memory *memoryTreeRepository) FindByOwner(ctx context.Context, owner string) ([]domain.Tree, error) {
+	if memory.tree.Owner != owner {
+		return []domain.Tree{}, nil
+	}
+	return []domain.Tree{memory.tree}, nil
+}
+
+func (memory *memoryTreeRepository) Update(ctx context.Context, tree domain.Tree) (domain.Tree, error) {
+	if memory.interleaved != nil {
+		memory.interleaved(&memory.tree)
+	}
+	if tree.ID != memory.tree.ID || tree.Owner != memory.tree.Owner {
+		return tree, repository.ErrNoData
+	}
+	if tree.Version != memory.tree.Version {
+		return tree, repository.ErrStaleData
+	}
+	tree.Version++
+	memory.tree = tree
+	return tree, nil
+}
+
+func TestTreeConcurrentUpdates(t *testing.T) {
+	treeRepository := &memoryTreeRepository{tree: domain.Tree{ID: "1", Nodes: []domain.TreeNode{}, Owner: "owner"}}
+	treeService := NewTreeService(treeRepository, nil, validator.New())
+
+	// Another insert lands between the read and the update of the first attempt
+	other := int64(1)
+	treeRepository.interleaved = func(tree *domain.Tree) {
+		treeRepository.interleaved = nil
+		bst := structure.NewBST(tree.Nodes)
+		bst.Insert(other)
+		tree.Nodes, tree.Version = bst.Nodes(), tree.Version+1
+	}
+
+	key := int64(2)
+	if _, err := treeService.InsertFromOwner("1", "owner", web.TreeNodeRequest{Key: &key}); err != nil {
+		t.Fatal(err)
+	}
+	bst := structure.NewBST(treeRepository.tree.Nodes)
+	if !bst.Contains(other) || !bst.Contains(key) {
+		t.Fatalf("expected both keys to be kept, got %v", treeRepository.tree.Nodes)
+	}
+
+	// A tree that keeps changing is reported as a conflict
+	treeRepository.interleaved = func(tree *domain.Tree) {
+		tree.Version++
+	}
+	if _, err := treeService.DeleteFromOwner("1", "owner", key); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if _, err := treeService.DeleteFromOwner("1", "intruder", key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"godas/model/domain"
 	"godas/model/web"
 	"godas/repository"
+	"godas/secure"
 	"os"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -16,22 +18,29 @@ type UserService interface {
 	FindById(string) (web.UserResponse, error)
 	FindAll() ([]web.UserResponse, error)
 	Update(string, web.UserUpdateRequest) (web.UserResponse, error)
+	SetRoles(web.AuthResponse, string, web.UserRolesRequest) error
 	Delete(string) error
 	Resend(web.EmailVerificationRecreateRequest) error
 	Verify(web.EmailVerificationCreateRequest) (web.UserResponse, error)
+	ChangeEmail(string, web.EmailChangeRequest) error
+	VerifyEmailChange(string, web.EmailVerificationCreateRequest) (web.UserResponse, error)
 }
 
 type UserServiceImpl struct {
 	userRepository           repository.UserRepository
+	roleRepository           repository.RoleRepository
 	emailVerificationService EmailVerificationService
 	validate                 *validator.Validate
+	passwordHasher           *secure.PasswordHasher
 }
 
-func NewUserService(userRepository repository.UserRepository, emailVerificationService EmailVerificationService, validate *validator.Validate) UserService {
+func NewUserService(userRepository repository.UserRepository, roleRepository repository.RoleRepository, emailVerificationService EmailVerificationService, validate *validator.Validate, passwordHasher *secure.PasswordHasher) UserService {
 	userService := new(UserServiceImpl)
 	userService.userRepository = userRepository
+	userService.roleRepository = roleRepository
 	userService.emailVerificationService = emailVerificationService
 	userService.validate = validate
+	userService.passwordHasher = passwordHasher
 
 	return userService
 }
@@ -39,34 +48,25 @@ func NewUserService(userRepository repository.UserRepository, emailVerificationS
 func (service *UserServiceImpl) Create(request web.UserCreateRequest) (web.UserResponse, error) {
 	response := web.UserResponse{}
 
-	if err := service.validate.Struct(request); err != nil {
+	if err := service.validate.Struct(request); err != nil || !validPasswordLength(request.Password) {
 		return response, ErrBadRequest
 	}
 
+	password, err := service.passwordHasher.Hash(request.Password)
+	if err != nil {
+		return response, err
+	}
+
 	user := domain.User{
 		Name:     request.Name,
 		Role:     domain.UserRoleClient,
+		Roles:    []string{domain.RoleClient},
 		Email:    request.Email,
-		Password: request.Password,
+		Password: password,
 		Verified: false,
 	}
 
-	emailVerificationSendChannel := make(chan error)
-
-	go func() {
-		_, err := service.emailVerificationService.Create(domain.EmailVerificationSend{
-			FromName:     os.Getenv("APP_NAME"),
-			FromEmail:    os.Getenv("EMAIL"),
-			FromPassword: os.Getenv("EMAIL_PASSWORD"),
-			ToEmail:      user.Email,
-			Title:        "Email Verification",
-			Host:         os.Getenv("EMAIL_HOST"),
-			Port:         os.Getenv("EMAIL_PORT"),
-		})
-		emailVerificationSendChannel <- err
-	}()
-
-	user, err := service.userRepository.Insert(context.Background(), user)
+	user, err = service.userRepository.Insert(context.Background(), user)
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateData) {
 			return response, ErrDuplicate
@@ -74,7 +74,14 @@ func (service *UserServiceImpl) Create(request web.UserCreateRequest) (web.UserR
 		return response, err
 	}
 
-	if err := <-emailVerificationSendChannel; err != nil {
+	// Only an address that was accepted is sent a code, a failed send can be retried with a resend
+	if _, err := service.emailVerificationService.Create(domain.EmailVerificationSend{
+		FromName:  os.Getenv("APP_NAME"),
+		FromEmail: os.Getenv("EMAIL"),
+		ToEmail:   user.Email,
+		Purpose:   domain.EmailVerificationPurposeSignup,
+		Title:     "Email Verification",
+	}); err != nil {
 		return response, err
 	}
 
@@ -129,12 +136,7 @@ func (service *UserServiceImpl) Update(id string, request web.UserUpdateRequest)
 		return response, ErrBadRequest
 	}
 
-	user := domain.User{
-		ID:   id,
-		Name: request.Name,
-	}
-
-	user, err := service.userRepository.Update(context.Background(), user)
+	user, err := service.userRepository.SetName(context.Background(), id, request.Name)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
@@ -150,6 +152,45 @@ func (service *UserServiceImpl) Update(id string, request web.UserUpdateRequest)
 	return response, nil
 }
 
+// Replace the roles of a user, the access tokens of the user are revoked so that none of them
+// carries the previous roles. Refresh tokens are kept as new tokens are issued with the stored roles.
+// Roles can only be given when the caller holds every permission they grant
+func (service *UserServiceImpl) SetRoles(authResponse web.AuthResponse, id string, request web.UserRolesRequest) error {
+	if err := service.validate.Struct(request); err != nil {
+		return ErrBadRequest
+	}
+
+	roles := []string{}
+	for _, name := range request.Roles {
+		if _, err := service.roleRepository.FindById(context.Background(), name); err != nil {
+			if errors.Is(err, repository.ErrNoData) {
+				return ErrBadRequest
+			}
+			return err
+		}
+		roles = append(roles, name)
+	}
+
+	permissions, err := rolePermissions(context.Background(), service.roleRepository, roles)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !authResponse.Can(permission) {
+			return ErrForbidden
+		}
+	}
+
+	if err := service.userRepository.SetRoles(context.Background(), id, roles, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (service *UserServiceImpl) Delete(id string) error {
 	if err := service.userRepository.Delete(context.Background(), domain.User{ID: id}); err != nil {
 		if errors.Is(err, repository.ErrNoData) {
@@ -166,13 +207,11 @@ func (service *UserServiceImpl) Resend(request web.EmailVerificationRecreateRequ
 	}
 
 	_, err := service.emailVerificationService.Recreate(domain.EmailVerificationSend{
-		FromName:     os.Getenv("APP_NAME"),
-		FromEmail:    os.Getenv("EMAIL"),
-		FromPassword: os.Getenv("EMAIL_PASSWORD"),
-		ToEmail:      request.Email,
-		Title:        "Email Verification",
-		Host:         os.Getenv("EMAIL_HOST"),
-		Port:         os.Getenv("EMAIL_PORT"),
+		FromName:  os.Getenv("APP_NAME"),
+		FromEmail: os.Getenv("EMAIL"),
+		ToEmail:   request.Email,
+		Purpose:   domain.EmailVerificationPurposeSignup,
+		Title:     "Email Verification",
 	})
 	if err != nil {
 		return err
@@ -188,7 +227,7 @@ func (service *UserServiceImpl) Verify(request web.EmailVerificationCreateReques
 		return response, ErrBadRequest
 	}
 
-	if err := service.emailVerificationService.Verification(request); err != nil {
+	if _, err := service.emailVerificationService.Verification(domain.EmailVerificationPurposeSignup, "", request); err != nil {
 		return response, err
 	}
 
@@ -199,9 +238,8 @@ func (service *UserServiceImpl) Verify(request web.EmailVerificationCreateReques
 		}
 		return response, err
 	}
-	user.Verified = true
-	_, err = service.userRepository.Update(context.Background(), user)
-	if err != nil {
+	// Only the flag is written, the rest of the user may have changed since it was read
+	if err := service.userRepository.SetVerified(context.Background(), user.ID); err != nil {
 		if errors.Is(err, repository.ErrNoData) {
 			return response, ErrNotFound
 		}
@@ -215,3 +253,74 @@ func (service *UserServiceImpl) Verify(request web.EmailVerificationCreateReques
 
 	return response, nil
 }
+
+// Send a code to the new address of a user, the email only changes once the code is verified
+func (service *UserServiceImpl) ChangeEmail(id string, request web.EmailChangeRequest) error {
+	if err := service.validate.Struct(request); err != nil {
+		return ErrBadRequest
+	}
+
+	user, err := service.userRepository.FindById(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if match, _ := service.passwordHasher.Verify(user.Password, request.Password); !match {
+		return ErrUnauthorized
+	}
+	if request.Email == user.Email {
+		return ErrBadRequest
+	}
+
+	if _, err := service.userRepository.FindByEmail(context.Background(), request.Email); err == nil {
+		return ErrDuplicate
+	} else if !errors.Is(err, repository.ErrNoData) {
+		return err
+	}
+
+	email := domain.EmailVerificationSend{
+		FromName:  os.Getenv("APP_NAME"),
+		FromEmail: os.Getenv("EMAIL"),
+		ToEmail:   request.Email,
+		Purpose:   domain.EmailVerificationPurposeEmailChange,
+		UserID:    user.ID,
+		Title:     "Email Change",
+	}
+
+	// A new request for the same address replaces the pending one
+	_, err = service.emailVerificationService.Recreate(email)
+	if errors.Is(err, ErrNotFound) {
+		_, err = service.emailVerificationService.Create(email)
+	}
+
+	return err
+}
+
+// Swap the email of a user for the address the code was sent to
+func (service *UserServiceImpl) VerifyEmailChange(id string, request web.EmailVerificationCreateRequest) (web.UserResponse, error) {
+	response := web.UserResponse{}
+
+	if err := service.validate.Struct(request); err != nil {
+		return response, ErrBadRequest
+	}
+
+	// The code of a change asked for by another user is not found, guessing it does not use up its attempts
+	emailVerification, err := service.emailVerificationService.Verification(domain.EmailVerificationPurposeEmailChange, id, request)
+	if err != nil {
+		return response, err
+	}
+
+	// The address may have been taken since the code was sent
+	if err := service.userRepository.SetEmail(context.Background(), id, emailVerification.Email); err != nil {
+		if errors.Is(err, repository.ErrDuplicateData) {
+			return response, ErrDuplicate
+		} else if errors.Is(err, repository.ErrNoData) {
+			return response, ErrNotFound
+		}
+		return response, err
+	}
+
+	return service.FindById(id)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"godas/model/domain"
+	"godas/model/web"
+	"godas/secure"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Records the verifications that were sent, the emails themselves are tested with the email verification service
+type recordingEmailVerificationService struct {
+	EmailVerificationService
+	sent []domain.EmailVerificationSend
+}
+
+func (verification *recordingEmailVerificationService) Create(send domain.EmailVerificationSend) (domain.EmailVerification, error) {
+	verification.sent = append(verification.sent, send)
+	return domain.EmailVerification{Email: send.ToEmail, Purpose: send.Purpose}, nil
+}
+
+func TestCreateUser(t *testing.T) {
+	passwordHasher, err := secure.NewPasswordHasher(secure.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := &memoryUserRepository{users: map[string]domain.User{}}
+	emailVerificationService := &recordingEmailVerificationService{}
+	userService := NewUserService(userRepository, nil, emailVerificationService, validator.New(), passwordHasher)
+
+	request := web.UserCreateRequest{Name: "A", Email: "a@example.com", Password: "password"}
+	if _, err := userService.Create(request); err != nil {
+		t.Fatal(err)
+	}
+	if len(emailVerificationService.sent) != 1 || emailVerificationService.sent[0].ToEmail != "a@example.com" {
+		t.Fatalf("expected one verification to be sent, got %v", emailVerificationService.sent)
+	}
+
+	// An address that is already taken is not sent a code
+	if _, err := userService.Create(request); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+	if len(emailVerificationService.sent) != 1 {
+		t.Fatalf("expected no verification for a refused address, got %v", emailVerificationService.sent)
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	revokedAt := time.Now().Truncate(time.Millisecond)
+	userRepository := &memoryUserRepository{users: map[string]domain.User{
+		"1": {ID: "1", Email: "a@example.com", Name: "A", Password: "hash", TokensRevokedAt: revokedAt},
+	}}
+	userService := NewUserService(userRepository, nil, &acceptingEmailVerificationService{}, validator.New(), nil)
+
+	if _, err := userService.Verify(web.EmailVerificationCreateRequest{Email: "a@example.com", Code: "123456"}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := userRepository.users["1"]
+	if !user.Verified || user.Password != "hash" || !user.TokensRevokedAt.Equal(revokedAt) {
+		t.Fatalf("expected only the verification to change, got %+v", user)
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"godas/repository"
+)
+
+// How often a versioned document is updated while other updates keep changing it
+const versionedUpdateAttempts = 3
+
+// Run an update that reads the latest version of a document and writes it back only if that version
+// is still stored. The update runs again on a fresh read when the document was changed in the meantime,
+// and ErrConflict is returned once the attempts run out.
+func updateVersioned(update func() error) error {
+	for attempt := 0; attempt < versionedUpdateAttempts; attempt++ {
+		err := update()
+		if errors.Is(err, repository.ErrStaleData) {
+			continue
+		} else if errors.Is(err, repository.ErrNoData) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return ErrConflict
+}
@@ -0,0 +1,240 @@
+package structure
+
+import "godas/model/domain"
+
+type avlNode struct {
+	key    int64
+	value  string
+	height int
+	left   *avlNode
+	right  *avlNode
+}
+
+// AVL keeps the heights of both subtrees of every node within one of each other
+type AVL struct {
+	root      *avlNode
+	size      int
+	rotations []domain.Rotation
+}
+
+// Rebuild the tree from its flat representation, heights are recomputed
+func NewAVL(nodes []domain.OrderedMapNode) *AVL {
+	tree := new(AVL)
+	tree.root = tree.build(nodes, 0, make([]bool, len(nodes)))
+
+	return tree
+}
+
+func (tree *AVL) build(nodes []domain.OrderedMapNode, index int, visited []bool) *avlNode {
+	if index < 0 || index >= len(nodes) || visited[index] {
+		return nil
+	}
+	visited[index] = true
+
+	tree.size++
+	node := &avlNode{key: nodes[index].Key, value: nodes[index].Value}
+	node.left = tree.build(nodes, nodes[index].Left, visited)
+	node.right = tree.build(nodes, nodes[index].Right, visited)
+	node.updateHeight()
+
+	return node
+}
+
+func (tree *AVL) Nodes() []domain.OrderedMapNode {
+	nodes := make([]domain.OrderedMapNode, 0, tree.size)
+
+	var flatten func(node *avlNode) int
+	flatten = func(node *avlNode) int {
+		if node == nil {
+			return -1
+		}
+
+		index := len(nodes)
+		nodes = append(nodes, domain.OrderedMapNode{
+			Key:    node.key,
+			Value:  node.value,
+			Height: node.height,
+		})
+		nodes[index].Left = flatten(node.left)
+		nodes[index].Right = flatten(node.right)
+
+		return index
+	}
+	flatten(tree.root)
+
+	return nodes
+}
+
+func (tree *AVL) Entries() []Entry {
+	entries := make([]Entry, 0, tree.size)
+
+	var walk func(node *avlNode)
+	walk = func(node *avlNode) {
+		if node != nil {
+			walk(node.left)
+			entries = append(entries, Entry{Key: node.key, Value: node.value})
+			walk(node.right)
+		}
+	}
+	walk(tree.root)
+
+	return entries
+}
+
+func (tree *AVL) Size() int {
+	return tree.size
+}
+
+func (tree *AVL) Height() int {
+	return tree.root.getHeight()
+}
+
+func (tree *AVL) Get(key int64) (string, bool) {
+	node := tree.root
+	for node != nil {
+		switch {
+		case key < node.key:
+			node = node.left
+		case key > node.key:
+			node = node.right
+		default:
+			return node.value, true
+		}
+	}
+
+	return "", false
+}
+
+// Put replaces the value if the key already exists
+func (tree *AVL) Put(key int64, value string) (bool, []domain.Rotation) {
+	tree.rotations = []domain.Rotation{}
+
+	inserted := false
+	tree.root = tree.put(tree.root, key, value, &inserted)
+	if inserted {
+		tree.size++
+	}
+
+	return inserted, tree.rotations
+}
+
+func (tree *AVL) put(node *avlNode, key int64, value string, inserted *bool) *avlNode {
+	if node == nil {
+		*inserted = true
+		return &avlNode{key: key, value: value, height: 1}
+	}
+
+	switch {
+	case key < node.key:
+		node.left = tree.put(node.left, key, value, inserted)
+	case key > node.key:
+		node.right = tree.put(node.right, key, value, inserted)
+	default:
+		node.value = value
+		return node
+	}
+
+	return tree.rebalance(node)
+}
+
+func (tree *AVL) Delete(key int64) (string, bool, []domain.Rotation) {
+	tree.rotations = []domain.Rotation{}
+
+	value, deleted := "", false
+	tree.root = tree.delete(tree.root, key, &value, &deleted)
+	if deleted {
+		tree.size--
+	}
+
+	return value, deleted, tree.rotations
+}
+
+func (tree *AVL) delete(node *avlNode, key int64, value *string, deleted *bool) *avlNode {
+	if node == nil {
+		return nil
+	}
+
+	switch {
+	case key < node.key:
+		node.left = tree.delete(node.left, key, value, deleted)
+	case key > node.key:
+		node.right = tree.delete(node.right, key, value, deleted)
+	default:
+		*value, *deleted = node.value, true
+		if node.left == nil {
+			return node.right
+		}
+		if node.right == nil {
+			return node.left
+		}
+
+		// Replace the entry with its in-order successor, then delete the successor
+		successor := node.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		node.key, node.value = successor.key, successor.value
+		node.right = tree.delete(node.right, successor.key, new(string), new(bool))
+	}
+
+	return tree.rebalance(node)
+}
+
+func (tree *AVL) rebalance(node *avlNode) *avlNode {
+	node.updateHeight()
+
+	switch balance := node.balance(); {
+	case balance > 1:
+		if node.left.balance() < 0 {
+			node.left = tree.rotateLeft(node.left)
+		}
+		return tree.rotateRight(node)
+	case balance < -1:
+		if node.right.balance() > 0 {
+			node.right = tree.rotateRight(node.right)
+		}
+		return tree.rotateLeft(node)
+	}
+
+	return node
+}
+
+func (tree *AVL) rotateLeft(node *avlNode) *avlNode {
+	tree.rotations = append(tree.rotations, domain.Rotation{Direction: domain.RotationLeft, Key: node.key})
+
+	pivot := node.right
+	node.right = pivot.left
+	pivot.left = node
+	node.updateHeight()
+	pivot.updateHeight()
+
+	return pivot
+}
+
+func (tree *AVL) rotateRight(node *avlNode) *avlNode {
+	tree.rotations = append(tree.rotations, domain.Rotation{Direction: domain.RotationRight, Key: node.key})
+
+	pivot := node.left
+	node.left = pivot.right
+	pivot.right = node
+	node.updateHeight()
+	pivot.updateHeight()
+
+	return pivot
+}
+
+func (node *avlNode) getHeight() int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
+
+func (node *avlNode) updateHeight() {
+	node.height = 1 + larger(node.left.getHeight(), node.right.getHeight())
+}
+
+// Balance factor is the height of the left subtree minus the height of the right subtree
+func (node *avlNode) balance() int {
+	return node.left.getHeight() - node.right.getHeight()
+}
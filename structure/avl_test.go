@@ -0,0 +1,96 @@
+package structure_test
+
+import (
+	"godas/model/domain"
+	"godas/structure"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Verify ordering and the AVL balance invariant on the flat representation
+func checkAVL(t *testing.T, nodes []domain.OrderedMapNode) {
+	t.Helper()
+
+	var check func(index int, low int64, high int64) int
+	check = func(index int, low int64, high int64) int {
+		if index < 0 {
+			return 0
+		}
+		node := nodes[index]
+		if node.Key <= low || node.Key >= high {
+			t.Fatalf("key %d out of order", node.Key)
+		}
+		left := check(node.Left, low, node.Key)
+		right := check(node.Right, node.Key, high)
+		if left-right > 1 || right-left > 1 {
+			t.Fatalf("node %d is unbalanced: %d vs %d", node.Key, left, right)
+		}
+		height := left + 1
+		if right > left {
+			height = right + 1
+		}
+		if node.Height != height {
+			t.Fatalf("node %d has stale height %d", node.Key, node.Height)
+		}
+		return node.Height
+	}
+	if len(nodes) > 0 {
+		check(0, -1<<63, 1<<63-1)
+	}
+}
+
+func TestAVLRotations(t *testing.T) {
+	tree := structure.NewAVL(nil)
+
+	tree.Put(1, "a")
+	tree.Put(2, "b")
+	_, rotations := tree.Put(3, "c")
+	if expected := []domain.Rotation{{Direction: domain.RotationLeft, Key: 1}}; !reflect.DeepEqual(rotations, expected) {
+		t.Errorf("expected %v, got %v", expected, rotations)
+	}
+
+	// Left-right case needs a double rotation
+	tree = structure.NewAVL(nil)
+	tree.Put(3, "c")
+	tree.Put(1, "a")
+	_, rotations = tree.Put(2, "b")
+	expected := []domain.Rotation{
+		{Direction: domain.RotationLeft, Key: 1},
+		{Direction: domain.RotationRight, Key: 3},
+	}
+	if !reflect.DeepEqual(rotations, expected) {
+		t.Errorf("expected %v, got %v", expected, rotations)
+	}
+	if nodes := tree.Nodes(); nodes[0].Key != 2 {
+		t.Errorf("expected 2 at the root, got %d", nodes[0].Key)
+	}
+}
+
+func TestAVLRandomized(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := structure.NewAVL(nil)
+	entries := map[int64]string{}
+
+	for i := 0; i < 2000; i++ {
+		key := random.Int63n(300)
+		if random.Intn(3) == 0 {
+			_, deleted, _ := tree.Delete(key)
+			_, exists := entries[key]
+			if deleted != exists {
+				t.Fatalf("delete %d: expected %v, got %v", key, exists, deleted)
+			}
+			delete(entries, key)
+		} else {
+			tree.Put(key, "value")
+			entries[key] = "value"
+		}
+
+		// Round trip through the stored form like the service does
+		tree = structure.NewAVL(tree.Nodes())
+		checkAVL(t, tree.Nodes())
+		if tree.Size() != len(entries) {
+			t.Fatalf("expected size %d, got %d", len(entries), tree.Size())
+		}
+	}
+}
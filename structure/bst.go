@@ -0,0 +1,275 @@
+package structure
+
+import "godas/model/domain"
+
+type bstNode struct {
+	key   int64
+	left  *bstNode
+	right *bstNode
+}
+
+// BST is an unbalanced binary search tree of unique keys
+type BST struct {
+	root *bstNode
+	size int
+}
+
+// Rebuild the tree from its flat representation
+func NewBST(nodes []domain.TreeNode) *BST {
+	tree := new(BST)
+	tree.root = tree.build(nodes, 0, make([]bool, len(nodes)))
+
+	return tree
+}
+
+func (tree *BST) build(nodes []domain.TreeNode, index int, visited []bool) *bstNode {
+	// visited guards against cycles in corrupted data
+	if index < 0 || index >= len(nodes) || visited[index] {
+		return nil
+	}
+	visited[index] = true
+
+	tree.size++
+	node := &bstNode{key: nodes[index].Key}
+	node.left = tree.build(nodes, nodes[index].Left, visited)
+	node.right = tree.build(nodes, nodes[index].Right, visited)
+
+	return node
+}
+
+// Flatten the tree in pre-order
+func (tree *BST) Nodes() []domain.TreeNode {
+	nodes := make([]domain.TreeNode, 0, tree.size)
+
+	var flatten func(node *bstNode) int
+	flatten = func(node *bstNode) int {
+		if node == nil {
+			return -1
+		}
+
+		index := len(nodes)
+		nodes = append(nodes, domain.TreeNode{Key: node.key})
+		nodes[index].Left = flatten(node.left)
+		nodes[index].Right = flatten(node.right)
+
+		return index
+	}
+	flatten(tree.root)
+
+	return nodes
+}
+
+func (tree *BST) Size() int {
+	return tree.size
+}
+
+// Height counts the nodes on the longest path, so an empty tree has height 0
+func (tree *BST) Height() int {
+	var height func(node *bstNode) int
+	height = func(node *bstNode) int {
+		if node == nil {
+			return 0
+		}
+		return 1 + larger(height(node.left), height(node.right))
+	}
+
+	return height(tree.root)
+}
+
+// Insert returns false if the key already exists
+func (tree *BST) Insert(key int64) bool {
+	link := &tree.root
+	for *link != nil {
+		switch {
+		case key < (*link).key:
+			link = &(*link).left
+		case key > (*link).key:
+			link = &(*link).right
+		default:
+			return false
+		}
+	}
+
+	*link = &bstNode{key: key}
+	tree.size++
+
+	return true
+}
+
+// Delete returns false if the key does not exist
+func (tree *BST) Delete(key int64) bool {
+	link := &tree.root
+	for *link != nil && (*link).key != key {
+		if key < (*link).key {
+			link = &(*link).left
+		} else {
+			link = &(*link).right
+		}
+	}
+
+	node := *link
+	if node == nil {
+		return false
+	}
+
+	switch {
+	case node.left == nil:
+		*link = node.right
+	case node.right == nil:
+		*link = node.left
+	default:
+		// Replace the key with its in-order successor, then unlink the successor
+		successorLink := &node.right
+		for (*successorLink).left != nil {
+			successorLink = &(*successorLink).left
+		}
+		node.key = (*successorLink).key
+		*successorLink = (*successorLink).right
+	}
+	tree.size--
+
+	return true
+}
+
+func (tree *BST) Contains(key int64) bool {
+	node := tree.root
+	for node != nil {
+		switch {
+		case key < node.key:
+			node = node.left
+		case key > node.key:
+			node = node.right
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (tree *BST) Min() (int64, bool) {
+	if tree.root == nil {
+		return 0, false
+	}
+
+	node := tree.root
+	for node.left != nil {
+		node = node.left
+	}
+
+	return node.key, true
+}
+
+func (tree *BST) Max() (int64, bool) {
+	if tree.root == nil {
+		return 0, false
+	}
+
+	node := tree.root
+	for node.right != nil {
+		node = node.right
+	}
+
+	return node.key, true
+}
+
+// Successor returns the smallest key greater than the given key
+func (tree *BST) Successor(key int64) (int64, bool) {
+	var candidate *bstNode
+	node := tree.root
+	for node != nil {
+		if node.key > key {
+			candidate = node
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+
+	if candidate == nil {
+		return 0, false
+	}
+
+	return candidate.key, true
+}
+
+// Predecessor returns the largest key less than the given key
+func (tree *BST) Predecessor(key int64) (int64, bool) {
+	var candidate *bstNode
+	node := tree.root
+	for node != nil {
+		if node.key < key {
+			candidate = node
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+
+	if candidate == nil {
+		return 0, false
+	}
+
+	return candidate.key, true
+}
+
+func (tree *BST) Traverse(order domain.TraversalOrder) ([]int64, bool) {
+	keys := make([]int64, 0, tree.size)
+
+	var walk func(node *bstNode)
+	switch order {
+	case domain.TraversalInOrder:
+		walk = func(node *bstNode) {
+			if node != nil {
+				walk(node.left)
+				keys = append(keys, node.key)
+				walk(node.right)
+			}
+		}
+	case domain.TraversalPreOrder:
+		walk = func(node *bstNode) {
+			if node != nil {
+				keys = append(keys, node.key)
+				walk(node.left)
+				walk(node.right)
+			}
+		}
+	case domain.TraversalPostOrder:
+		walk = func(node *bstNode) {
+			if node != nil {
+				walk(node.left)
+				walk(node.right)
+				keys = append(keys, node.key)
+			}
+		}
+	case domain.TraversalLevelOrder:
+		walk = func(node *bstNode) {
+			queue := []*bstNode{}
+			if node != nil {
+				queue = append(queue, node)
+			}
+			for len(queue) > 0 {
+				node, queue = queue[0], queue[1:]
+				keys = append(keys, node.key)
+				if node.left != nil {
+					queue = append(queue, node.left)
+				}
+				if node.right != nil {
+					queue = append(queue, node.right)
+				}
+			}
+		}
+	default:
+		return nil, false
+	}
+	walk(tree.root)
+
+	return keys, true
+}
+
+func larger(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package structure_test
+
+import (
+	"godas/model/domain"
+	"godas/structure"
+	"reflect"
+	"testing"
+)
+
+func newBST(keys ...int64) *structure.BST {
+	tree := structure.NewBST(nil)
+	for _, key := range keys {
+		tree.Insert(key)
+	}
+	return tree
+}
+
+func TestBSTTraverse(t *testing.T) {
+	tree := newBST(50, 30, 70, 20, 40, 60, 80)
+
+	tests := map[domain.TraversalOrder][]int64{
+		domain.TraversalInOrder:    {20, 30, 40, 50, 60, 70, 80},
+		domain.TraversalPreOrder:   {50, 30, 20, 40, 70, 60, 80},
+		domain.TraversalPostOrder:  {20, 40, 30, 60, 80, 70, 50},
+		domain.TraversalLevelOrder: {50, 30, 70, 20, 40, 60, 80},
+	}
+	for order, expected := range tests {
+		keys, ok := tree.Traverse(order)
+		if !ok || !reflect.DeepEqual(keys, expected) {
+			t.Errorf("%s: expected %v, got %v", order, expected, keys)
+		}
+	}
+
+	if _, ok := tree.Traverse("sideways"); ok {
+		t.Error("expected unknown order to be rejected")
+	}
+}
+
+func TestBSTInsertDuplicate(t *testing.T) {
+	tree := newBST(1, 2, 3)
+	if tree.Insert(2) {
+		t.Error("expected duplicate insert to fail")
+	}
+	if tree.Size() != 3 {
+		t.Errorf("expected size 3, got %d", tree.Size())
+	}
+}
+
+func TestBSTDelete(t *testing.T) {
+	tree := newBST(50, 30, 70, 20, 40, 60, 80)
+
+	// Leaf, single child and two children
+	for _, key := range []int64{20, 30, 50} {
+		if !tree.Delete(key) {
+			t.Fatalf("expected %d to be deleted", key)
+		}
+		if tree.Contains(key) {
+			t.Fatalf("expected %d to be gone", key)
+		}
+	}
+	if tree.Delete(50) {
+		t.Error("expected deleting a missing key to fail")
+	}
+
+	keys, _ := tree.Traverse(domain.TraversalInOrder)
+	if expected := []int64{40, 60, 70, 80}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestBSTOrderQueries(t *testing.T) {
+	tree := newBST(50, 30, 70, 20, 40, 60, 80)
+
+	if key, ok := tree.Min(); !ok || key != 20 {
+		t.Errorf("expected min 20, got %d", key)
+	}
+	if key, ok := tree.Max(); !ok || key != 80 {
+		t.Errorf("expected max 80, got %d", key)
+	}
+	if key, ok := tree.Successor(40); !ok || key != 50 {
+		t.Errorf("expected successor 50, got %d", key)
+	}
+	if key, ok := tree.Predecessor(60); !ok || key != 50 {
+		t.Errorf("expected predecessor 50, got %d", key)
+	}
+	if _, ok := tree.Successor(80); ok {
+		t.Error("expected no successor of the max key")
+	}
+	if _, ok := structure.NewBST(nil).Min(); ok {
+		t.Error("expected no min in an empty tree")
+	}
+}
+
+func TestBSTNodesRoundTrip(t *testing.T) {
+	tree := newBST(50, 30, 70, 20)
+	nodes := tree.Nodes()
+
+	expected := []domain.TreeNode{
+		{Key: 50, Left: 1, Right: 3},
+		{Key: 30, Left: 2, Right: -1},
+		{Key: 20, Left: -1, Right: -1},
+		{Key: 70, Left: -1, Right: -1},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Fatalf("expected %v, got %v", expected, nodes)
+	}
+
+	rebuilt := structure.NewBST(nodes)
+	if rebuilt.Size() != 4 || rebuilt.Height() != 3 {
+		t.Errorf("expected size 4 and height 3, got %d and %d", rebuilt.Size(), rebuilt.Height())
+	}
+	if !reflect.DeepEqual(rebuilt.Nodes(), nodes) {
+		t.Error("expected rebuilt tree to flatten identically")
+	}
+}
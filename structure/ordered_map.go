@@ -0,0 +1,32 @@
+package structure
+
+import "godas/model/domain"
+
+type Entry struct {
+	Key   int64
+	Value string
+}
+
+// OrderedMap is a self-balancing binary search tree mapping keys to values.
+// Mutations report the rotations they performed, in order.
+type OrderedMap interface {
+	Put(key int64, value string) (inserted bool, rotations []domain.Rotation)
+	Get(key int64) (string, bool)
+	Delete(key int64) (value string, deleted bool, rotations []domain.Rotation)
+	Entries() []Entry
+	Nodes() []domain.OrderedMapNode
+	Size() int
+	Height() int
+}
+
+// Rebuild an ordered map of the given kind from its flat representation
+func NewOrderedMap(kind domain.OrderedMapKind, nodes []domain.OrderedMapNode) (OrderedMap, bool) {
+	switch kind {
+	case domain.OrderedMapAVL:
+		return NewAVL(nodes), true
+	case domain.OrderedMapRedBlack:
+		return NewRedBlack(nodes), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,358 @@
+package structure
+
+import "godas/model/domain"
+
+type redBlackNode struct {
+	key    int64
+	value  string
+	color  domain.NodeColor
+	left   *redBlackNode
+	right  *redBlackNode
+	parent *redBlackNode
+}
+
+// RedBlack is a red-black tree following the classic CLRS algorithms.
+// Leaves and the parent of the root point to a shared black sentinel instead of nil.
+type RedBlack struct {
+	root      *redBlackNode
+	sentinel  *redBlackNode
+	size      int
+	rotations []domain.Rotation
+}
+
+// Rebuild the tree from its flat representation, colors are trusted as stored
+func NewRedBlack(nodes []domain.OrderedMapNode) *RedBlack {
+	tree := new(RedBlack)
+	tree.sentinel = &redBlackNode{color: domain.NodeColorBlack}
+	tree.root = tree.build(nodes, 0, tree.sentinel, make([]bool, len(nodes)))
+
+	return tree
+}
+
+func (tree *RedBlack) build(nodes []domain.OrderedMapNode, index int, parent *redBlackNode, visited []bool) *redBlackNode {
+	if index < 0 || index >= len(nodes) || visited[index] {
+		return tree.sentinel
+	}
+	visited[index] = true
+
+	tree.size++
+	node := &redBlackNode{
+		key:    nodes[index].Key,
+		value:  nodes[index].Value,
+		color:  nodes[index].Color,
+		parent: parent,
+	}
+	if node.color != domain.NodeColorRed {
+		node.color = domain.NodeColorBlack
+	}
+	node.left = tree.build(nodes, nodes[index].Left, node, visited)
+	node.right = tree.build(nodes, nodes[index].Right, node, visited)
+
+	return node
+}
+
+func (tree *RedBlack) Nodes() []domain.OrderedMapNode {
+	nodes := make([]domain.OrderedMapNode, 0, tree.size)
+
+	var flatten func(node *redBlackNode) (int, int)
+	flatten = func(node *redBlackNode) (int, int) {
+		if node == tree.sentinel {
+			return -1, 0
+		}
+
+		index := len(nodes)
+		nodes = append(nodes, domain.OrderedMapNode{
+			Key:   node.key,
+			Value: node.value,
+			Color: node.color,
+		})
+		left, leftHeight := flatten(node.left)
+		right, rightHeight := flatten(node.right)
+		nodes[index].Left = left
+		nodes[index].Right = right
+		nodes[index].Height = 1 + larger(leftHeight, rightHeight)
+
+		return index, nodes[index].Height
+	}
+	flatten(tree.root)
+
+	return nodes
+}
+
+func (tree *RedBlack) Entries() []Entry {
+	entries := make([]Entry, 0, tree.size)
+
+	var walk func(node *redBlackNode)
+	walk = func(node *redBlackNode) {
+		if node != tree.sentinel {
+			walk(node.left)
+			entries = append(entries, Entry{Key: node.key, Value: node.value})
+			walk(node.right)
+		}
+	}
+	walk(tree.root)
+
+	return entries
+}
+
+func (tree *RedBlack) Size() int {
+	return tree.size
+}
+
+func (tree *RedBlack) Height() int {
+	var height func(node *redBlackNode) int
+	height = func(node *redBlackNode) int {
+		if node == tree.sentinel {
+			return 0
+		}
+		return 1 + larger(height(node.left), height(node.right))
+	}
+
+	return height(tree.root)
+}
+
+func (tree *RedBlack) Get(key int64) (string, bool) {
+	node := tree.find(key)
+	if node == tree.sentinel {
+		return "", false
+	}
+
+	return node.value, true
+}
+
+// Put replaces the value if the key already exists
+func (tree *RedBlack) Put(key int64, value string) (bool, []domain.Rotation) {
+	tree.rotations = []domain.Rotation{}
+
+	parent := tree.sentinel
+	node := tree.root
+	for node != tree.sentinel {
+		parent = node
+		switch {
+		case key < node.key:
+			node = node.left
+		case key > node.key:
+			node = node.right
+		default:
+			node.value = value
+			return false, tree.rotations
+		}
+	}
+
+	node = &redBlackNode{
+		key:    key,
+		value:  value,
+		color:  domain.NodeColorRed,
+		left:   tree.sentinel,
+		right:  tree.sentinel,
+		parent: parent,
+	}
+	switch {
+	case parent == tree.sentinel:
+		tree.root = node
+	case key < parent.key:
+		parent.left = node
+	default:
+		parent.right = node
+	}
+	tree.size++
+	tree.insertFixup(node)
+
+	return true, tree.rotations
+}
+
+func (tree *RedBlack) insertFixup(node *redBlackNode) {
+	for node.parent.color == domain.NodeColorRed {
+		grandparent := node.parent.parent
+		if node.parent == grandparent.left {
+			uncle := grandparent.right
+			if uncle.color == domain.NodeColorRed {
+				node.parent.color = domain.NodeColorBlack
+				uncle.color = domain.NodeColorBlack
+				grandparent.color = domain.NodeColorRed
+				node = grandparent
+				continue
+			}
+			if node == node.parent.right {
+				node = node.parent
+				tree.rotateLeft(node)
+			}
+			node.parent.color = domain.NodeColorBlack
+			node.parent.parent.color = domain.NodeColorRed
+			tree.rotateRight(node.parent.parent)
+		} else {
+			uncle := grandparent.left
+			if uncle.color == domain.NodeColorRed {
+				node.parent.color = domain.NodeColorBlack
+				uncle.color = domain.NodeColorBlack
+				grandparent.color = domain.NodeColorRed
+				node = grandparent
+				continue
+			}
+			if node == node.parent.left {
+				node = node.parent
+				tree.rotateRight(node)
+			}
+			node.parent.color = domain.NodeColorBlack
+			node.parent.parent.color = domain.NodeColorRed
+			tree.rotateLeft(node.parent.parent)
+		}
+	}
+	tree.root.color = domain.NodeColorBlack
+}
+
+func (tree *RedBlack) Delete(key int64) (string, bool, []domain.Rotation) {
+	tree.rotations = []domain.Rotation{}
+
+	node := tree.find(key)
+	if node == tree.sentinel {
+		return "", false, tree.rotations
+	}
+
+	var child *redBlackNode
+	removedColor := node.color
+	switch {
+	case node.left == tree.sentinel:
+		child = node.right
+		tree.transplant(node, node.right)
+	case node.right == tree.sentinel:
+		child = node.left
+		tree.transplant(node, node.left)
+	default:
+		successor := node.right
+		for successor.left != tree.sentinel {
+			successor = successor.left
+		}
+		removedColor = successor.color
+		child = successor.right
+		if successor.parent == node {
+			child.parent = successor
+		} else {
+			tree.transplant(successor, successor.right)
+			successor.right = node.right
+			successor.right.parent = successor
+		}
+		tree.transplant(node, successor)
+		successor.left = node.left
+		successor.left.parent = successor
+		successor.color = node.color
+	}
+	tree.size--
+
+	if removedColor == domain.NodeColorBlack {
+		tree.deleteFixup(child)
+	}
+	tree.sentinel.parent = nil
+
+	return node.value, true, tree.rotations
+}
+
+func (tree *RedBlack) deleteFixup(node *redBlackNode) {
+	for node != tree.root && node.color == domain.NodeColorBlack {
+		if node == node.parent.left {
+			sibling := node.parent.right
+			if sibling.color == domain.NodeColorRed {
+				sibling.color = domain.NodeColorBlack
+				node.parent.color = domain.NodeColorRed
+				tree.rotateLeft(node.parent)
+				sibling = node.parent.right
+			}
+			if sibling.left.color == domain.NodeColorBlack && sibling.right.color == domain.NodeColorBlack {
+				sibling.color = domain.NodeColorRed
+				node = node.parent
+				continue
+			}
+			if sibling.right.color == domain.NodeColorBlack {
+				sibling.left.color = domain.NodeColorBlack
+				sibling.color = domain.NodeColorRed
+				tree.rotateRight(sibling)
+				sibling = node.parent.right
+			}
+			sibling.color = node.parent.color
+			node.parent.color = domain.NodeColorBlack
+			sibling.right.color = domain.NodeColorBlack
+			tree.rotateLeft(node.parent)
+			node = tree.root
+		} else {
+			sibling := node.parent.left
+			if sibling.color == domain.NodeColorRed {
+				sibling.color = domain.NodeColorBlack
+				node.parent.color = domain.NodeColorRed
+				tree.rotateRight(node.parent)
+				sibling = node.parent.left
+			}
+			if sibling.left.color == domain.NodeColorBlack && sibling.right.color == domain.NodeColorBlack {
+				sibling.color = domain.NodeColorRed
+				node = node.parent
+				continue
+			}
+			if sibling.left.color == domain.NodeColorBlack {
+				sibling.right.color = domain.NodeColorBlack
+				sibling.color = domain.NodeColorRed
+				tree.rotateLeft(sibling)
+				sibling = node.parent.left
+			}
+			sibling.color = node.parent.color
+			node.parent.color = domain.NodeColorBlack
+			sibling.left.color = domain.NodeColorBlack
+			tree.rotateRight(node.parent)
+			node = tree.root
+		}
+	}
+	node.color = domain.NodeColorBlack
+}
+
+func (tree *RedBlack) find(key int64) *redBlackNode {
+	node := tree.root
+	for node != tree.sentinel {
+		switch {
+		case key < node.key:
+			node = node.left
+		case key > node.key:
+			node = node.right
+		default:
+			return node
+		}
+	}
+
+	return tree.sentinel
+}
+
+// Replace the subtree rooted at old with the subtree rooted at replacement
+func (tree *RedBlack) transplant(old *redBlackNode, replacement *redBlackNode) {
+	switch {
+	case old.parent == tree.sentinel:
+		tree.root = replacement
+	case old == old.parent.left:
+		old.parent.left = replacement
+	default:
+		old.parent.right = replacement
+	}
+	replacement.parent = old.parent
+}
+
+func (tree *RedBlack) rotateLeft(node *redBlackNode) {
+	tree.rotations = append(tree.rotations, domain.Rotation{Direction: domain.RotationLeft, Key: node.key})
+
+	pivot := node.right
+	node.right = pivot.left
+	if pivot.left != tree.sentinel {
+		pivot.left.parent = node
+	}
+	tree.transplant(node, pivot)
+	pivot.left = node
+	node.parent = pivot
+}
+
+func (tree *RedBlack) rotateRight(node *redBlackNode) {
+	tree.rotations = append(tree.rotations, domain.Rotation{Direction: domain.RotationRight, Key: node.key})
+
+	pivot := node.left
+	node.left = pivot.right
+	if pivot.right != tree.sentinel {
+		pivot.right.parent = node
+	}
+	tree.transplant(node, pivot)
+	pivot.right = node
+	node.parent = pivot
+}
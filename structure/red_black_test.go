@@ -0,0 +1,101 @@
+package structure_test
+
+import (
+	"godas/model/domain"
+	"godas/structure"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Verify ordering, no red node with a red child and equal black heights
+func checkRedBlack(t *testing.T, nodes []domain.OrderedMapNode) {
+	t.Helper()
+
+	var check func(index int, low int64, high int64, parentColor domain.NodeColor) int
+	check = func(index int, low int64, high int64, parentColor domain.NodeColor) int {
+		if index < 0 {
+			return 1
+		}
+		node := nodes[index]
+		if node.Key <= low || node.Key >= high {
+			t.Fatalf("key %d out of order", node.Key)
+		}
+		if node.Color == domain.NodeColorRed && parentColor == domain.NodeColorRed {
+			t.Fatalf("red node %d has a red parent", node.Key)
+		}
+		left := check(node.Left, low, node.Key, node.Color)
+		right := check(node.Right, node.Key, high, node.Color)
+		if left != right {
+			t.Fatalf("node %d has unequal black heights: %d vs %d", node.Key, left, right)
+		}
+		if node.Color == domain.NodeColorBlack {
+			left++
+		}
+		return left
+	}
+	if len(nodes) > 0 {
+		if nodes[0].Color != domain.NodeColorBlack {
+			t.Fatal("expected a black root")
+		}
+		check(0, -1<<63, 1<<63-1, domain.NodeColorBlack)
+	}
+}
+
+func TestRedBlackRotations(t *testing.T) {
+	tree := structure.NewRedBlack(nil)
+
+	tree.Put(1, "a")
+	tree.Put(2, "b")
+	_, rotations := tree.Put(3, "c")
+	if expected := []domain.Rotation{{Direction: domain.RotationLeft, Key: 1}}; !reflect.DeepEqual(rotations, expected) {
+		t.Errorf("expected %v, got %v", expected, rotations)
+	}
+
+	// Recoloring only, the uncle of 4 is red
+	_, rotations = tree.Put(4, "d")
+	if len(rotations) != 0 {
+		t.Errorf("expected no rotations, got %v", rotations)
+	}
+
+	nodes := tree.Nodes()
+	checkRedBlack(t, nodes)
+	if nodes[0].Key != 2 {
+		t.Errorf("expected 2 at the root, got %d", nodes[0].Key)
+	}
+}
+
+func TestRedBlackRandomized(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := structure.NewRedBlack(nil)
+	entries := map[int64]string{}
+
+	for i := 0; i < 2000; i++ {
+		key := random.Int63n(300)
+		if random.Intn(3) == 0 {
+			value, deleted, _ := tree.Delete(key)
+			expected, exists := entries[key]
+			if deleted != exists || value != expected {
+				t.Fatalf("delete %d: expected %v, got %v", key, exists, deleted)
+			}
+			delete(entries, key)
+		} else {
+			tree.Put(key, "value")
+			entries[key] = "value"
+		}
+
+		tree = structure.NewRedBlack(tree.Nodes())
+		checkRedBlack(t, tree.Nodes())
+		if tree.Size() != len(entries) {
+			t.Fatalf("expected size %d, got %d", len(entries), tree.Size())
+		}
+	}
+
+	previous := int64(-1)
+	for _, entry := range tree.Entries() {
+		if entry.Key <= previous {
+			t.Fatalf("entries out of order at %d", entry.Key)
+		}
+		previous = entry.Key
+	}
+}